@@ -61,6 +61,11 @@ type App struct {
 	ourWindowHandle      uintptr
 	ignoreFocusLoss      bool        // NEW: Flag to temporarily ignore focus loss
 	focusLossTimer       *time.Timer // NEW: Timer for delayed focus loss detection
+	searchMutex          sync.Mutex
+	cancelSearch         context.CancelFunc // Stops the query started by the last StartSearch
+	lastQuery            string             // What the results on screen were found for; recorded with opens
+	savedNew             map[string]bool    // Paths new to the saved search on screen since it was last opened
+	savedOpen            string             // Saved search the user picked; savedNew belongs to it
 }
 
 func NewApp() *App {
@@ -427,15 +432,108 @@ func loadExtIcons() {
 	}
 }
 
-func (a *App) Search(query string) []core.SearchResult {
+// SearchUpdate is one batch of results for the query the frontend tagged with QueryID.
+type SearchUpdate struct {
+	QueryID int
+	Page    core.SearchPage
+	Final   bool // Semantic results are merged in; no more updates for this query
+}
+
+// StartSearch cancels the previous query and streams this one as
+// "search:results" events: keyword and filename matches first, then the
+// ranking with semantic results merged in.
+func (a *App) StartSearch(queryID int, query string, opts core.SearchOptions) {
+	saved, isSaved := core.ResolveSavedSearch(query)
+
+	a.searchMutex.Lock()
+	if a.cancelSearch != nil {
+		a.cancelSearch()
+	}
+	ctx, cancel := context.WithCancel(a.ctx)
+	a.cancelSearch = cancel
+	a.lastQuery = query
+	// Badges stay while the picked saved search is re-run, e.g. when the window reopens
+	if !isSaved || !strings.EqualFold(saved.Name, a.savedOpen) {
+		a.savedNew, a.savedOpen = nil, ""
+	}
+	a.searchMutex.Unlock()
+
 	if query == "" {
-		return []core.SearchResult{}
+		cancel()
+		return
+	}
+
+	go func() {
+		defer cancel()
+		// A saved search's name or !alias runs its stored query
+		search := query
+		if isSaved {
+			search = saved.Query
+		}
+
+		opts.Progress = func(page core.SearchPage) {
+			wruntime.EventsEmit(a.ctx, "search:results", SearchUpdate{QueryID: queryID, Page: a.decorateResults(query, opts, page)})
+		}
+		page, err := core.HybridSearch(ctx, search, opts)
+		if ctx.Err() != nil {
+			return // Superseded; the frontend has moved on
+		}
+		if err != nil {
+			page = core.SearchPage{}
+		}
+		wruntime.EventsEmit(a.ctx, "search:results", SearchUpdate{QueryID: queryID, Page: a.decorateResults(query, opts, page), Final: true})
+	}()
+}
+
+// CancelSearch stops the running query, e.g. when the search box is cleared.
+func (a *App) CancelSearch() {
+	a.searchMutex.Lock()
+	defer a.searchMutex.Unlock()
+	if a.cancelSearch != nil {
+		a.cancelSearch()
+		a.cancelSearch = nil
 	}
+}
 
-	results, _ := core.HybridSearch(query)
+// Search returns one page of results; pass the previous page's NextCursor
+// (or an Offset) in opts to continue.
+func (a *App) Search(query string, opts core.SearchOptions) core.SearchPage {
+	if query == "" {
+		return core.SearchPage{Results: []core.SearchResult{}}
+	}
+
+	search := query
+	if saved, ok := core.ResolveSavedSearch(query); ok {
+		search = saved.Query
+	}
+	page, _ := core.HybridSearch(a.ctx, search, opts)
+	return a.decorateResults(query, opts, page)
+}
+
+// decorateResults adds the settings shortcut and matching saved searches to
+// first pages, flags matches new to a saved search and fills in extension
+// icons for results without their own.
+func (a *App) decorateResults(query string, opts core.SearchOptions, page core.SearchPage) core.SearchPage {
+	results := page.Results
+	if results == nil {
+		results = []core.SearchResult{}
+	}
+
+	a.searchMutex.Lock()
+	fresh := a.savedNew
+	a.searchMutex.Unlock()
+	for i := range results {
+		results[i].New = fresh[results[i].Path]
+	}
 
 	lowerQ := strings.ToLower(query)
-	if strings.Contains("settings", lowerQ) || strings.Contains("config", lowerQ) {
+	firstPage := opts.Offset <= 0 && opts.Cursor == ""
+	if firstPage && strings.HasPrefix(lowerQ, "!") {
+		suggestions := a.savedSearchSuggestions(strings.TrimPrefix(lowerQ, "!"))
+		results = append(suggestions, results...)
+		page.Total += len(suggestions)
+	}
+	if firstPage && (strings.Contains("settings", lowerQ) || strings.Contains("config", lowerQ)) {
 		settingsRes := core.SearchResult{
 			Path:      "anything://settings",
 			Snippet:   "Configure AI, Indexing, and Hotkeys",
@@ -444,8 +542,40 @@ func (a *App) Search(query string) []core.SearchResult {
 			Extension: ".settings",
 		}
 		results = append([]core.SearchResult{settingsRes}, results...)
+		page.Total++
 	}
 
+	page.Results = fillIcons(results)
+	return page
+}
+
+// savedSearchSuggestions lists the saved searches whose alias or name starts
+// with prefix, as entries that recall them. Runs on every keystroke, so
+// nothing is searched here; new match counts are in the settings tab.
+func (a *App) savedSearchSuggestions(prefix string) []core.SearchResult {
+	list, err := core.ListSavedSearches(a.ctx)
+	if err != nil {
+		fmt.Printf("Error listing saved searches: %v\n", err)
+	}
+	var suggestions []core.SearchResult
+	for _, s := range list {
+		alias, name := strings.ToLower(s.Alias), strings.ToLower(s.Name)
+		if !strings.HasPrefix(alias, prefix) && !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		suggestions = append(suggestions, core.SearchResult{
+			Path:      "anything://saved/" + s.Name,
+			Name:      s.Name,
+			Snippet:   s.Query,
+			Score:     1000.0,
+			Extension: ".saved",
+		})
+	}
+	return suggestions
+}
+
+// fillIcons gives results without their own icon the cached one of their extension.
+func fillIcons(results []core.SearchResult) []core.SearchResult {
 	for i := range results {
 		if results[i].IconData != "" {
 			continue
@@ -458,14 +588,137 @@ func (a *App) Search(query string) []core.SearchResult {
 	return results
 }
 
+// FindSimilar returns files like the given result ("more like this").
+func (a *App) FindSimilar(path string) []core.SearchResult {
+	// Opens from this list count toward frecency but teach no query anything
+	a.searchMutex.Lock()
+	a.lastQuery = ""
+	a.searchMutex.Unlock()
+
+	results, err := core.FindSimilar(a.ctx, path)
+	if err != nil {
+		fmt.Printf("Error finding files similar to %s: %v\n", path, err)
+	}
+	if results == nil {
+		results = []core.SearchResult{}
+	}
+	return fillIcons(results)
+}
+
+// OpenSavedSearch is called when the user picks a saved search: it becomes
+// opened now, and the searches for it that follow flag the matches it had
+// not shown before. Typing its name or alias alone only runs the query.
+func (a *App) OpenSavedSearch(name string) error {
+	fresh, err := core.OpenSavedSearch(a.ctx, name)
+	if err != nil {
+		return err
+	}
+	a.searchMutex.Lock()
+	a.savedNew, a.savedOpen = fresh, name
+	a.searchMutex.Unlock()
+	return nil
+}
+
+// SaveSearch stores query as a saved search, recalled by typing its name or !alias.
+func (a *App) SaveSearch(name, alias, query string) error {
+	return core.SaveSearch(a.ctx, name, alias, query)
+}
+
+// DeleteSavedSearch removes a saved search.
+func (a *App) DeleteSavedSearch(name string) error {
+	return core.DeleteSavedSearch(name)
+}
+
+// GetSavedSearches lists the saved searches with their new match counts.
+func (a *App) GetSavedSearches() []core.SavedSearch {
+	list, err := core.ListSavedSearches(a.ctx)
+	if err == nil {
+		err = core.CountNewMatches(a.ctx, list)
+	}
+	if err != nil {
+		fmt.Printf("Error listing saved searches: %v\n", err)
+	}
+	if list == nil {
+		list = []core.SavedSearch{}
+	}
+	return list
+}
+
+// GetNewMatches returns what a saved search matches now but did not when it was last opened.
+func (a *App) GetNewMatches(name string) []core.SearchResult {
+	results, err := core.NewMatches(a.ctx, name)
+	if err != nil {
+		fmt.Printf("Error checking saved search %q: %v\n", name, err)
+	}
+	if results == nil {
+		results = []core.SearchResult{}
+	}
+	return fillIcons(results)
+}
+
+// ExplainSearch returns the score components behind each result of a query
+// and also sends them to the debug panel as a "search:explain" event.
+func (a *App) ExplainSearch(query string) core.SearchExplanation {
+	if saved, ok := core.ResolveSavedSearch(query); ok {
+		query = saved.Query
+	}
+	explanation, err := core.ExplainSearch(a.ctx, query)
+	if err != nil {
+		fmt.Printf("Error explaining %q: %v\n", query, err)
+	}
+	wruntime.EventsEmit(a.ctx, "search:explain", explanation)
+	return explanation
+}
+
+// ForgetPath clears the open history of a path so it stops being boosted.
+func (a *App) ForgetPath(path string) {
+	if err := core.ForgetPath(path); err != nil {
+		fmt.Printf("Error forgetting %s: %v\n", path, err)
+	}
+}
+
+// GetMetadata returns the structured fields (EXIF etc.) indexed for a file.
+func (a *App) GetMetadata(path string) core.Metadata {
+	return core.GetFileMetadata(path)
+}
+
+// GetEntities returns the emails, URLs, phone numbers, IBANs, amounts and dates found in a file.
+func (a *App) GetEntities(path string) []core.Entity {
+	return core.GetFileEntities(path)
+}
+
+// GetFlaggedFiles lists the files in which passwords, keys, card numbers or IDs were found.
+func (a *App) GetFlaggedFiles() []core.FlaggedFile {
+	return core.GetFlaggedFiles()
+}
+
+// GetOutgoingLinks returns the notes a Markdown note links to.
+func (a *App) GetOutgoingLinks(path string) []string {
+	return core.GetOutgoingLinks(path)
+}
+
+// GetBacklinks returns the notes that link to a Markdown note.
+func (a *App) GetBacklinks(path string) []string {
+	return core.GetBacklinks(path)
+}
+
 func (a *App) OpenFile(path string) {
 	if path == "anything://settings" {
 		a.OpenSettings()
 		return
 	}
+	if strings.HasPrefix(path, "anything://saved/") {
+		return // The launcher recalls the saved search itself
+	}
 
 	fmt.Printf("Opening: %s\n", path)
-	go core.IncrementUsage(path)
+	a.searchMutex.Lock()
+	query := a.lastQuery
+	a.searchMutex.Unlock()
+	go core.RecordOpen(path, query)
+
+	// Calendar events / contacts open their .ics / .vcf
+	path = core.ParentPath(path)
 
 	var cmd *exec.Cmd
 	if runtime.GOOS == "windows" {
@@ -0,0 +1,177 @@
+package core
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	acronymMinLen     = 2
+	acronymMaxLen     = 8
+	acronymCandidates = 200
+	acronymMaxResults = 15
+	initialsBatch     = 1000 // Rows backfilled per transaction
+)
+
+// Launchable entries; they win ties when an acronym fits several files
+var appExtensions = map[string]bool{".lnk": true, ".exe": true, ".desktop": true}
+
+func isAppPath(path string) bool {
+	return appExtensions[strings.ToLower(filepath.Ext(path))]
+}
+
+// nameWords splits a filename stem at spaces/punctuation, camelCase humps and
+// letter/digit changes: "HybridSearch" -> Hybrid Search, "XMLParser2" -> XML Parser 2.
+func nameWords(name string) []string {
+	var words []string
+	runes := []rune(name)
+	start := -1
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsNumber(r) {
+			if start >= 0 {
+				words = append(words, string(runes[start:i]))
+				start = -1
+			}
+			continue
+		}
+		if start >= 0 {
+			prev := runes[i-1]
+			boundary := (unicode.IsLower(prev) && unicode.IsUpper(r)) ||
+				(unicode.IsLetter(prev) != unicode.IsLetter(r)) ||
+				(unicode.IsUpper(prev) && unicode.IsUpper(r) && i+1 < len(runes) && unicode.IsLower(runes[i+1]))
+			if boundary {
+				words = append(words, string(runes[start:i]))
+				start = i
+			}
+		} else {
+			start = i
+		}
+	}
+	if start >= 0 {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
+
+// nameInitials is the lowercase first letter of every word of the stem:
+// "Visual Studio Code.lnk" -> "vsc", "hybrid_search.go" -> "hs".
+func nameInitials(filename string) string {
+	var sb strings.Builder
+	for _, w := range nameWords(strings.TrimSuffix(filename, extOf(filename))) {
+		for _, r := range w {
+			sb.WriteRune(unicode.ToLower(r))
+			break
+		}
+	}
+	return sb.String()
+}
+
+// refreshInitials fills files.initials for rows indexed before the column
+// existed (new rows get theirs on insert), a batch per transaction.
+func refreshInitials() {
+	for {
+		rows, err := DB.Query("SELECT id, filename FROM files WHERE initials IS NULL LIMIT ?", initialsBatch)
+		if err != nil {
+			return
+		}
+		type pending struct {
+			ID       int
+			Initials string
+		}
+		var updates []pending
+		for rows.Next() {
+			var id int
+			var filename string
+			if rows.Scan(&id, &filename) == nil {
+				updates = append(updates, pending{id, nameInitials(filename)})
+			}
+		}
+		rows.Close()
+		if len(updates) == 0 {
+			return
+		}
+
+		tx, err := DB.Begin()
+		if err != nil {
+			return
+		}
+		stmt, err := tx.Prepare("UPDATE files SET initials = ? WHERE id = ?")
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		for _, u := range updates {
+			if _, err := stmt.Exec(u.Initials, u.ID); err != nil {
+				stmt.Close()
+				tx.Rollback()
+				return
+			}
+		}
+		stmt.Close()
+		if tx.Commit() != nil {
+			return
+		}
+	}
+}
+
+// isAcronymQuery accepts what a launcher user types for an abbreviation: one
+// short ASCII word ("vsc", "gc", "hs").
+func isAcronymQuery(q string) bool {
+	if len(q) < acronymMinLen || len(q) > acronymMaxLen {
+		return false
+	}
+	for i := 0; i < len(q); i++ {
+		c := q[i]
+		if !(c >= 'a' && c <= 'z') && !(c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// AcronymSearch matches the query against word initials, like a command
+// palette: "vsc" finds Visual Studio Code, "hs" finds HybridSearch.go.
+// Exact initials score 1, longer names proportionally less; apps come first.
+func AcronymSearch(ctx context.Context, query string, filters QueryFilters) ([]SearchResult, error) {
+	q := strings.ToLower(query)
+	if !isAcronymQuery(q) {
+		return nil, nil
+	}
+
+	// Prefix range on the initials index: "vsc" <= initials < "vsd"
+	upper := q[:len(q)-1] + string(q[len(q)-1]+1)
+	sqlQuery := `
+		SELECT f.path, f.initials, COALESCE(f.icon_data, ''), f.extension
+		FROM files f
+		WHERE f.initials >= ? AND f.initials < ? `
+	args := []interface{}{q, upper}
+	clauses, clauseArgs := filters.sqlClauses()
+	sqlQuery += clauses + ` ORDER BY lower(f.extension) IN ('.lnk', '.exe', '.desktop') DESC, length(f.initials) LIMIT ?`
+	args = append(append(args, clauseArgs...), acronymCandidates)
+
+	rows, err := DB.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []SearchResult
+	for rows.Next() {
+		var res SearchResult
+		var initials string
+		if err := rows.Scan(&res.Path, &initials, &res.IconData, &res.Extension); err != nil {
+			continue
+		}
+		res.Score = 0.5 + 0.5*float32(len(q))/float32(len(initials))
+		results = append(results, res)
+		if len(results) >= acronymMaxResults {
+			break
+		}
+	}
+	// Best coverage first; the query's order keeps apps ahead on ties
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	return results, nil
+}
@@ -15,6 +15,20 @@ var (
 	IsAIReady   bool = false
 )
 
+// EmbeddingModel is a sentence-embedding ONNX model with its WordPiece vocab.
+type EmbeddingModel struct {
+	Name       string
+	HiddenSize int
+	Session    *onnxruntime_go.DynamicSession[int64, float32]
+	Vocab      map[string]int64 // nil = the default vocab.txt
+}
+
+const DefaultModelName = "minilm"
+
+// Models holds every loaded embedding model by name. Vectors are tagged with
+// the model that produced them and only compared against the same model.
+var Models = map[string]*EmbeddingModel{}
+
 const (
 	ModelUrl     = "https://huggingface.co/optimum/all-MiniLM-L6-v2/resolve/main/model.onnx"
 	VocabUrl     = "https://huggingface.co/optimum/all-MiniLM-L6-v2/raw/main/vocab.txt"
@@ -101,13 +115,65 @@ func InitAI() {
 	}
 
 	ORT_Session = session
+	Models[DefaultModelName] = &EmbeddingModel{Name: DefaultModelName, HiddenSize: 384, Session: session}
 	IsAIReady = true
 	fmt.Println("✅ [AI] Semantic Engine Ready.")
+
+	loadLanguageModels()
+}
+
+// loadLanguageModels loads the optional per-language models from settings.
+// They are not downloaded; <name>.onnx and <name>-vocab.txt must be in the data folder.
+func loadLanguageModels() {
+	for lang, cfg := range CurrentSettings.LanguageModels {
+		if cfg.Name == "" || Models[cfg.Name] != nil {
+			continue
+		}
+		modelPath := GetDataPath(cfg.Name + ".onnx")
+		vocabPath := GetDataPath(cfg.Name + "-vocab.txt")
+		if _, err := os.Stat(modelPath); err != nil {
+			fmt.Printf("⚠️  [AI] Model for '%s' not found at %s. Using default.\n", lang, modelPath)
+			continue
+		}
+
+		vocab, err := loadVocab(vocabPath)
+		if err != nil {
+			fmt.Printf("❌ [AI Error] Could not load %s: %v\n", vocabPath, err)
+			continue
+		}
+		session, err := onnxruntime_go.NewDynamicSession[int64, float32](
+			modelPath,
+			[]string{"input_ids", "attention_mask", "token_type_ids"},
+			[]string{"last_hidden_state"},
+		)
+		if err != nil {
+			fmt.Printf("❌ [AI Error] Failed to load model %s: %v\n", cfg.Name, err)
+			continue
+		}
+
+		hidden := cfg.HiddenSize
+		if hidden <= 0 {
+			hidden = 384
+		}
+		Models[cfg.Name] = &EmbeddingModel{Name: cfg.Name, HiddenSize: hidden, Session: session, Vocab: vocab}
+		fmt.Printf("✅ [AI] Loaded model '%s'.\n", cfg.Name)
+	}
+}
+
+// ModelForLanguage picks the embedding model configured for a language code,
+// falling back to the default model.
+func ModelForLanguage(lang string) *EmbeddingModel {
+	if cfg, ok := CurrentSettings.LanguageModels[lang]; ok {
+		if m := Models[cfg.Name]; m != nil {
+			return m
+		}
+	}
+	return Models[DefaultModelName]
 }
 
 func CloseAI() {
-	if ORT_Session != nil {
-		ORT_Session.Destroy()
+	for _, m := range Models {
+		m.Session.Destroy()
 	}
 	onnxruntime_go.DestroyEnvironment()
 }
@@ -142,12 +208,21 @@ func downloadOnnxRuntime(targetLibName string) error {
 
 // --- EMBEDDING ENGINE ---
 
+// GetEmbedding embeds text with the default model.
 func GetEmbedding(text string) ([]float32, error) {
-	if !IsAIReady || ORT_Session == nil {
+	return EmbedWith(Models[DefaultModelName], text)
+}
+
+func EmbedWith(m *EmbeddingModel, text string) ([]float32, error) {
+	if !IsAIReady || m == nil || m.Session == nil {
 		return nil, fmt.Errorf("AI engine not ready")
 	}
 
-	inputIDSlice := Tokenize(text)
+	vocab := m.Vocab
+	if vocab == nil {
+		vocab = Vocab
+	}
+	inputIDSlice := tokenizeWith(vocab, text)
 	seqLength := int64(len(inputIDSlice))
 
 	inputIDs := make([]int64, seqLength)
@@ -167,13 +242,13 @@ func GetEmbedding(text string) ([]float32, error) {
 	tType, _ := onnxruntime_go.NewTensor(shape, tokenTypeIDs)
 
 	// Prepare Output (Pre-allocated for DynamicSession)
-	hiddenSize := 384
+	hiddenSize := m.HiddenSize
 	outputShape := []int64{1, seqLength, int64(hiddenSize)}
 	outputData := make([]float32, 1*seqLength*int64(hiddenSize))
 
 	tOutput, _ := onnxruntime_go.NewTensor(outputShape, outputData)
 
-	err := ORT_Session.Run(
+	err := m.Session.Run(
 		[]*onnxruntime_go.Tensor[int64]{tInput, tMask, tType},
 		[]*onnxruntime_go.Tensor[float32]{tOutput},
 	)
@@ -39,7 +39,7 @@ func RunAppScan() {
 		return
 	}
 
-	insertQuery := `INSERT INTO files (path, filename, extension, modified_time, summary, icon_data) VALUES (?, ?, ?, ?, ?, ?)`
+	insertQuery := `INSERT INTO files (path, filename, extension, modified_time, summary, icon_data, initials) VALUES (?, ?, ?, ?, ?, ?, ?)`
 	insertStmt, err := tx.Prepare(insertQuery)
 	if err != nil {
 		fmt.Printf("❌ Error preparing insert: %v\n(Hint: Delete index.db to reset schema)\n", err)
@@ -85,7 +85,7 @@ func RunAppScan() {
 					err = DB.QueryRow("SELECT modified_time FROM files WHERE path = ?", path).Scan(&storedTime)
 
 					if err == sql.ErrNoRows {
-						_, err = insertStmt.Exec(path, d.Name(), ext, currentModTime, appSummary, iconData)
+						_, err = insertStmt.Exec(path, d.Name(), ext, currentModTime, appSummary, iconData, nameInitials(d.Name()))
 						if err == nil {
 							count++
 						}
@@ -0,0 +1,649 @@
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// AudioTags is what we index for music, podcasts and recordings.
+type AudioTags struct {
+	Title    string
+	Artist   string
+	Album    string
+	Genre    string
+	Year     string
+	Date     time.Time // Full release/recording date when the tag has one
+	Duration float64   // Seconds
+}
+
+func isAudioFile(ext string) bool {
+	switch strings.ToLower(ext) {
+	case ".mp3", ".flac", ".ogg", ".oga", ".opus", ".m4a":
+		return true
+	}
+	return false
+}
+
+// ReadAudioTags picks the tag format from the extension.
+func ReadAudioTags(path string) (*AudioTags, error) {
+	return readAudioTagsAs(path, filepath.Ext(path))
+}
+
+// readAudioTagsAs reads tags in the format of ext, which may differ from the
+// file's own extension when the type was sniffed.
+func readAudioTagsAs(path, ext string) (*AudioTags, error) {
+	switch strings.ToLower(ext) {
+	case ".mp3":
+		return readMP3Tags(path)
+	case ".flac":
+		return readFlacTags(path)
+	case ".ogg", ".oga", ".opus":
+		return readOggTags(path)
+	case ".m4a":
+		info, err := ReadMP4(path)
+		if err != nil {
+			return nil, err
+		}
+		tags := &AudioTags{
+			Title:    info.Tags["title"],
+			Artist:   info.Tags["artist"],
+			Album:    info.Tags["album"],
+			Genre:    info.Tags["genre"],
+			Duration: info.Duration,
+		}
+		if tags.Artist == "" {
+			tags.Artist = info.Tags["album_artist"]
+		}
+		tags.setDate(info.Tags["date"])
+		return tags, nil
+	}
+	return nil, fmt.Errorf("unsupported audio format")
+}
+
+// setDate accepts "2023", "2023-05-14" or "2023-05-14T10:00:00Z".
+func (t *AudioTags) setDate(value string) {
+	value = strings.TrimSpace(value)
+	if len(value) < 4 {
+		return
+	}
+	if _, err := strconv.Atoi(value[:4]); err != nil {
+		return
+	}
+	t.Year = value[:4]
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02", "2006-01"} {
+		if d, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			t.Date = d
+			return
+		}
+	}
+}
+
+// addTo stores the tags as metadata and searchable text.
+func (t *AudioTags) addTo(ext *Extraction) {
+	var desc []string
+	add := func(key, label, value string) {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return
+		}
+		ext.Metadata.Add(key, value)
+		desc = append(desc, label+": "+value)
+	}
+
+	add("artist", "Artist", t.Artist)
+	add("album", "Album", t.Album)
+	add("title", "Title", t.Title)
+	add("year", "Year", t.Year)
+	add("genre", "Genre", t.Genre)
+
+	if t.Duration > 0 {
+		ext.Metadata.Add("duration", strconv.Itoa(int(t.Duration+0.5)))
+		desc = append(desc, "Duration: "+formatDuration(t.Duration))
+	}
+
+	// Release date drives the date filters ("podcast episodes from 2023")
+	if !t.Date.IsZero() {
+		ext.ContentTime = t.Date.Unix()
+	} else if year, err := strconv.Atoi(t.Year); err == nil && year > 0 {
+		ext.ContentTime = time.Date(year, 1, 1, 0, 0, 0, 0, time.Local).Unix()
+	}
+
+	ext.appendText(strings.Join(desc, " "))
+}
+
+func formatDuration(seconds float64) string {
+	s := int(seconds + 0.5)
+	if s >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, (s/60)%60, s%60)
+	}
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
+
+// --- MP3 (ID3v2 / ID3v1) ---
+
+func readMP3Tags(path string) (*AudioTags, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	tags := &AudioTags{}
+	var audioStart int64
+	var lengthMs int64
+
+	hdr := make([]byte, 10)
+	if _, err := f.ReadAt(hdr, 0); err == nil && string(hdr[:3]) == "ID3" {
+		size := int64(syncsafe(hdr[6:10]))
+		audioStart = 10 + size
+		if hdr[5]&0x10 != 0 { // Footer present
+			audioStart += 10
+		}
+		// Cover art can make tags huge; the text frames come first in practice
+		readSize := size
+		if readSize > 1024*1024 {
+			readSize = 1024 * 1024
+		}
+		body := make([]byte, readSize)
+		if n, _ := f.ReadAt(body, 10); n > 0 {
+			lengthMs = parseID3v2(body[:n], hdr[3], hdr[5], tags)
+		}
+	}
+
+	// ID3v1 only fills what v2 didn't provide
+	end := stat.Size()
+	v1 := make([]byte, 128)
+	if end >= 128 {
+		if _, err := f.ReadAt(v1, end-128); err == nil && string(v1[:3]) == "TAG" {
+			end -= 128
+			fill := func(dst *string, b []byte) {
+				if *dst == "" {
+					*dst = strings.TrimSpace(string(bytes.TrimRight(b, "\x00 ")))
+				}
+			}
+			fill(&tags.Title, v1[3:33])
+			fill(&tags.Artist, v1[33:63])
+			fill(&tags.Album, v1[63:93])
+			fill(&tags.Year, v1[93:97])
+			if tags.Genre == "" {
+				tags.Genre = id3Genre(int(v1[127]))
+			}
+		}
+	}
+
+	if lengthMs > 0 {
+		tags.Duration = float64(lengthMs) / 1000
+	} else {
+		tags.Duration = mp3Duration(f, audioStart, end)
+	}
+	return tags, nil
+}
+
+func syncsafe(b []byte) uint32 {
+	return uint32(b[0]&0x7f)<<21 | uint32(b[1]&0x7f)<<14 | uint32(b[2]&0x7f)<<7 | uint32(b[3]&0x7f)
+}
+
+// parseID3v2 fills tags from the frames and returns TLEN (ms) if present.
+func parseID3v2(body []byte, version byte, flags byte, tags *AudioTags) int64 {
+	if flags&0x80 != 0 && version < 4 {
+		// Tag-wide unsynchronisation: FF 00 -> FF
+		body = bytes.ReplaceAll(body, []byte{0xFF, 0x00}, []byte{0xFF})
+	}
+
+	pos := 0
+	if flags&0x40 != 0 && len(body) >= 4 { // Extended header
+		if version == 4 {
+			pos = int(syncsafe(body[:4]))
+		} else {
+			pos = int(binary.BigEndian.Uint32(body[:4])) + 4
+		}
+	}
+
+	idLen, hdrLen := 4, 10
+	if version == 2 {
+		idLen, hdrLen = 3, 6
+	}
+
+	var lengthMs int64
+	var date, year string
+
+	for pos+hdrLen <= len(body) {
+		id := string(body[pos : pos+idLen])
+		if id[0] == 0 {
+			break // Padding
+		}
+
+		var size int
+		switch version {
+		case 2:
+			size = int(body[pos+3])<<16 | int(body[pos+4])<<8 | int(body[pos+5])
+		case 3:
+			size = int(binary.BigEndian.Uint32(body[pos+4:]))
+		default:
+			size = int(syncsafe(body[pos+4 : pos+8]))
+		}
+		pos += hdrLen
+		if size <= 0 || pos+size > len(body) {
+			break
+		}
+		frame := body[pos : pos+size]
+		pos += size
+
+		if id[0] != 'T' {
+			continue
+		}
+		value := decodeID3Text(frame)
+
+		switch id {
+		case "TIT2", "TT2":
+			tags.Title = value
+		case "TPE1", "TP1":
+			tags.Artist = value
+		case "TPE2", "TP2":
+			if tags.Artist == "" {
+				tags.Artist = value
+			}
+		case "TALB", "TAL":
+			tags.Album = value
+		case "TCON", "TCO":
+			tags.Genre = cleanID3Genre(value)
+		case "TYER", "TYE":
+			year = value
+		case "TDRC", "TDOR":
+			if date == "" {
+				date = value
+			}
+		case "TLEN", "TLE":
+			lengthMs, _ = strconv.ParseInt(value, 10, 64)
+		}
+	}
+
+	if date != "" {
+		tags.setDate(date)
+	} else if year != "" {
+		tags.setDate(year)
+	}
+	return lengthMs
+}
+
+func decodeID3Text(frame []byte) string {
+	if len(frame) < 2 {
+		return ""
+	}
+	enc, data := frame[0], frame[1:]
+
+	var s string
+	switch enc {
+	case 1, 2: // UTF-16 (with BOM) / UTF-16BE
+		var order binary.ByteOrder = binary.BigEndian
+		if len(data) >= 2 && data[0] == 0xFF && data[1] == 0xFE {
+			order = binary.LittleEndian
+			data = data[2:]
+		} else if len(data) >= 2 && data[0] == 0xFE && data[1] == 0xFF {
+			data = data[2:]
+		}
+		u := make([]uint16, 0, len(data)/2)
+		for i := 0; i+1 < len(data); i += 2 {
+			u = append(u, order.Uint16(data[i:]))
+		}
+		s = string(utf16.Decode(u))
+	case 3:
+		s = string(data)
+	default: // ISO-8859-1
+		r := make([]rune, len(data))
+		for i, b := range data {
+			r[i] = rune(b)
+		}
+		s = string(r)
+	}
+
+	// v2.4 separates multiple values with NUL
+	s = strings.TrimRight(s, "\x00")
+	return strings.TrimSpace(strings.ReplaceAll(s, "\x00", ", "))
+}
+
+// cleanID3Genre resolves "(17)" / "17" references to the ID3v1 genre list.
+func cleanID3Genre(value string) string {
+	if strings.HasPrefix(value, "(") {
+		if end := strings.Index(value, ")"); end > 0 {
+			if n, err := strconv.Atoi(value[1:end]); err == nil {
+				if rest := strings.TrimSpace(value[end+1:]); rest != "" {
+					return rest
+				}
+				return id3Genre(n)
+			}
+		}
+	}
+	if n, err := strconv.Atoi(value); err == nil {
+		return id3Genre(n)
+	}
+	return value
+}
+
+var id3Genres = []string{
+	"Blues", "Classic Rock", "Country", "Dance", "Disco", "Funk", "Grunge", "Hip-Hop",
+	"Jazz", "Metal", "New Age", "Oldies", "Other", "Pop", "R&B", "Rap", "Reggae", "Rock",
+	"Techno", "Industrial", "Alternative", "Ska", "Death Metal", "Pranks", "Soundtrack",
+	"Euro-Techno", "Ambient", "Trip-Hop", "Vocal", "Jazz+Funk", "Fusion", "Trance",
+	"Classical", "Instrumental", "Acid", "House", "Game", "Sound Clip", "Gospel", "Noise",
+	"Alternative Rock", "Bass", "Soul", "Punk", "Space", "Meditative", "Instrumental Pop",
+	"Instrumental Rock", "Ethnic", "Gothic", "Darkwave", "Techno-Industrial", "Electronic",
+	"Pop-Folk", "Eurodance", "Dream", "Southern Rock", "Comedy", "Cult", "Gangsta", "Top 40",
+	"Christian Rap", "Pop/Funk", "Jungle", "Native American", "Cabaret", "New Wave",
+	"Psychedelic", "Rave", "Showtunes", "Trailer", "Lo-Fi", "Tribal", "Acid Punk",
+	"Acid Jazz", "Polka", "Retro", "Musical", "Rock & Roll", "Hard Rock",
+}
+
+func id3Genre(n int) string {
+	if n >= 0 && n < len(id3Genres) {
+		return id3Genres[n]
+	}
+	if n == 186 { // Winamp extension, common on podcast feeds
+		return "Podcast"
+	}
+	return ""
+}
+
+// MPEG audio tables: [version][layer][index] in kbps; version 0 = MPEG1, 1 = MPEG2/2.5
+var mp3Bitrates = [2][3][16]int{
+	{
+		{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448, 0},
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384, 0},
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0},
+	},
+	{
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256, 0},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+	},
+}
+
+var mp3SampleRates = map[byte][3]int{
+	3: {44100, 48000, 32000}, // MPEG1
+	2: {22050, 24000, 16000}, // MPEG2
+	0: {11025, 12000, 8000},  // MPEG2.5
+}
+
+// mp3Duration reads the first frame: Xing/Info/VBRI headers give the exact
+// frame count for VBR files, otherwise we assume constant bitrate.
+func mp3Duration(r io.ReaderAt, start, end int64) float64 {
+	buf := make([]byte, 64*1024)
+	n, _ := r.ReadAt(buf, start)
+	buf = buf[:n]
+
+	for i := 0; i+4 <= len(buf); i++ {
+		if buf[i] != 0xFF || buf[i+1]&0xE0 != 0xE0 {
+			continue
+		}
+		versionBits := (buf[i+1] >> 3) & 0x03
+		layerBits := (buf[i+1] >> 1) & 0x03
+		bitrateIdx := buf[i+2] >> 4
+		rateIdx := (buf[i+2] >> 2) & 0x03
+		if versionBits == 1 || layerBits == 0 || bitrateIdx == 0 || bitrateIdx == 15 || rateIdx == 3 {
+			continue
+		}
+
+		v := 0
+		if versionBits != 3 {
+			v = 1
+		}
+		layer := 3 - int(layerBits) // 0 = Layer I
+		bitrate := mp3Bitrates[v][layer][bitrateIdx] * 1000
+		sampleRate := mp3SampleRates[versionBits][rateIdx]
+
+		samplesPerFrame := 1152
+		if layer == 0 {
+			samplesPerFrame = 384
+		} else if layer == 2 && v == 1 {
+			samplesPerFrame = 576
+		}
+
+		mono := (buf[i+3] >> 6) == 3
+		sideInfo := 32
+		switch {
+		case v == 0 && mono:
+			sideInfo = 17
+		case v == 1 && mono:
+			sideInfo = 9
+		case v == 1:
+			sideInfo = 17
+		}
+
+		frame := buf[i:]
+		if x := 4 + sideInfo; len(frame) >= x+12 {
+			tag := string(frame[x : x+4])
+			if tag == "Xing" || tag == "Info" {
+				if binary.BigEndian.Uint32(frame[x+4:])&0x01 != 0 {
+					frames := binary.BigEndian.Uint32(frame[x+8:])
+					return float64(frames) * float64(samplesPerFrame) / float64(sampleRate)
+				}
+			}
+		}
+		if len(frame) >= 36+18 && string(frame[36:40]) == "VBRI" {
+			frames := binary.BigEndian.Uint32(frame[36+14:])
+			return float64(frames) * float64(samplesPerFrame) / float64(sampleRate)
+		}
+
+		audioBytes := end - (start + int64(i))
+		if bitrate == 0 || audioBytes <= 0 {
+			return 0
+		}
+		return float64(audioBytes) * 8 / float64(bitrate)
+	}
+	return 0
+}
+
+// --- FLAC / OGG (Vorbis comments) ---
+
+func readFlacTags(path string) (*AudioTags, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(f, magic); err != nil || string(magic) != "fLaC" {
+		return nil, fmt.Errorf("not a flac file")
+	}
+
+	tags := &AudioTags{}
+	hdr := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(f, hdr); err != nil {
+			break
+		}
+		last := hdr[0]&0x80 != 0
+		kind := hdr[0] & 0x7F
+		length := int64(hdr[1])<<16 | int64(hdr[2])<<8 | int64(hdr[3])
+
+		switch kind {
+		case 0: // STREAMINFO
+			info := make([]byte, length)
+			if _, err := io.ReadFull(f, info); err != nil || len(info) < 18 {
+				return tags, nil
+			}
+			sampleRate := uint64(info[10])<<12 | uint64(info[11])<<4 | uint64(info[12])>>4
+			totalSamples := uint64(info[13]&0x0F)<<32 | uint64(binary.BigEndian.Uint32(info[14:18]))
+			if sampleRate > 0 {
+				tags.Duration = float64(totalSamples) / float64(sampleRate)
+			}
+		case 4: // VORBIS_COMMENT
+			comment := make([]byte, length)
+			if _, err := io.ReadFull(f, comment); err != nil {
+				return tags, nil
+			}
+			applyVorbisComments(comment, tags)
+		default:
+			if _, err := f.Seek(length, io.SeekCurrent); err != nil {
+				return tags, nil
+			}
+		}
+		if last {
+			break
+		}
+	}
+	return tags, nil
+}
+
+func readOggTags(path string) (*AudioTags, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	packets, err := readOggPackets(f, 2, 1024*1024)
+	if err != nil || len(packets) == 0 {
+		return nil, fmt.Errorf("not an ogg stream")
+	}
+
+	tags := &AudioTags{}
+	sampleRate := 0
+	preSkip := 0
+
+	ident := packets[0]
+	switch {
+	case bytes.HasPrefix(ident, []byte("\x01vorbis")) && len(ident) >= 16:
+		sampleRate = int(binary.LittleEndian.Uint32(ident[12:]))
+	case bytes.HasPrefix(ident, []byte("OpusHead")) && len(ident) >= 12:
+		sampleRate = 48000 // Opus granule positions are always 48 kHz
+		preSkip = int(binary.LittleEndian.Uint16(ident[10:]))
+	default:
+		return nil, fmt.Errorf("unsupported ogg codec")
+	}
+
+	if len(packets) > 1 {
+		comment := packets[1]
+		switch {
+		case bytes.HasPrefix(comment, []byte("\x03vorbis")):
+			applyVorbisComments(comment[7:], tags)
+		case bytes.HasPrefix(comment, []byte("OpusTags")):
+			applyVorbisComments(comment[8:], tags)
+		}
+	}
+
+	if granule := lastOggGranule(f); granule > 0 && sampleRate > 0 {
+		tags.Duration = float64(granule-int64(preSkip)) / float64(sampleRate)
+	}
+	return tags, nil
+}
+
+// readOggPackets reassembles the first n packets of the stream.
+func readOggPackets(r io.Reader, n int, limit int) ([][]byte, error) {
+	var packets [][]byte
+	var current []byte
+	hdr := make([]byte, 27)
+
+	for len(packets) < n {
+		if _, err := io.ReadFull(r, hdr); err != nil {
+			return packets, err
+		}
+		if string(hdr[:4]) != "OggS" {
+			return packets, fmt.Errorf("lost ogg sync")
+		}
+		segTable := make([]byte, hdr[26])
+		if _, err := io.ReadFull(r, segTable); err != nil {
+			return packets, err
+		}
+		for _, lacing := range segTable {
+			seg := make([]byte, lacing)
+			if _, err := io.ReadFull(r, seg); err != nil {
+				return packets, err
+			}
+			if len(current) < limit {
+				current = append(current, seg...)
+			}
+			if lacing < 255 {
+				packets = append(packets, current)
+				current = nil
+				if len(packets) >= n {
+					break
+				}
+			}
+		}
+	}
+	return packets, nil
+}
+
+func lastOggGranule(f *os.File) int64 {
+	stat, err := f.Stat()
+	if err != nil {
+		return 0
+	}
+	size := stat.Size()
+	tailLen := int64(64 * 1024)
+	if tailLen > size {
+		tailLen = size
+	}
+	tail := make([]byte, tailLen)
+	if _, err := f.ReadAt(tail, size-tailLen); err != nil && err != io.EOF {
+		return 0
+	}
+	idx := bytes.LastIndex(tail, []byte("OggS"))
+	if idx < 0 || idx+14 > len(tail) {
+		return 0
+	}
+	return int64(binary.LittleEndian.Uint64(tail[idx+6:]))
+}
+
+// applyVorbisComments parses vendor + "KEY=value" list (shared by FLAC and OGG).
+func applyVorbisComments(data []byte, tags *AudioTags) {
+	if len(data) < 8 {
+		return
+	}
+	vendorLen := int(binary.LittleEndian.Uint32(data))
+	pos := 4 + vendorLen
+	if pos+4 > len(data) {
+		return
+	}
+	count := int(binary.LittleEndian.Uint32(data[pos:]))
+	pos += 4
+
+	var date string
+	for i := 0; i < count && pos+4 <= len(data); i++ {
+		l := int(binary.LittleEndian.Uint32(data[pos:]))
+		pos += 4
+		if l < 0 || pos+l > len(data) {
+			break
+		}
+		entry := string(data[pos : pos+l])
+		pos += l
+
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			continue
+		}
+		switch strings.ToUpper(key) {
+		case "TITLE":
+			tags.Title = value
+		case "ARTIST":
+			tags.Artist = value
+		case "ALBUMARTIST":
+			if tags.Artist == "" {
+				tags.Artist = value
+			}
+		case "ALBUM":
+			tags.Album = value
+		case "GENRE":
+			tags.Genre = value
+		case "DATE", "YEAR":
+			date = value
+		}
+	}
+	tags.setDate(date)
+}
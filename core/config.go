@@ -0,0 +1,169 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+const MaxConfigSize = 2 * 1024 * 1024 // Bigger "JSON" is data (lockfiles, dumps), not config
+const MaxConfigEntries = 2000
+const MaxConfigKeys = 500 // Stored as metadata for key: filters
+
+// readConfigContent flattens JSON/YAML/TOML into "database.host = localhost"
+// lines and stores each dotted key path as "key" metadata.
+func readConfigContent(path string) Extraction {
+	res := newExtraction("")
+
+	stat, err := os.Stat(path)
+	if err != nil || stat.Size() > MaxConfigSize {
+		return res
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return res
+	}
+
+	docs, err := parseConfig(strings.ToLower(filepath.Ext(path)), data)
+	if err != nil {
+		return res
+	}
+
+	var entries []configEntry
+	for _, doc := range docs {
+		flattenConfig("", doc, &entries)
+	}
+
+	var sb strings.Builder
+	for _, e := range entries {
+		if len(res.Metadata["key"]) < MaxConfigKeys {
+			res.Metadata.Add("key", e.Key)
+		}
+		if sb.Len() > 0 {
+			sb.WriteByte(' ')
+		}
+		if e.Value == "" {
+			sb.WriteString(e.Key)
+		} else {
+			sb.WriteString(e.Key + " = " + e.Value)
+		}
+		if sb.Len() > MaxReadSize {
+			break
+		}
+	}
+
+	res.Text = cleanText(sb.String(), false)
+	return res
+}
+
+// parseConfig decodes every document in the file (YAML allows several per file).
+func parseConfig(ext string, data []byte) ([]interface{}, error) {
+	switch ext {
+	case ".json":
+		var doc interface{}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if err := dec.Decode(&doc); err != nil {
+			return nil, err
+		}
+		return []interface{}{doc}, nil
+	case ".yaml", ".yml":
+		var docs []interface{}
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		for {
+			var doc interface{}
+			err := dec.Decode(&doc)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				if len(docs) > 0 {
+					break // Keep what parsed before the broken document
+				}
+				return nil, err
+			}
+			docs = append(docs, doc)
+		}
+		return docs, nil
+	case ".toml":
+		var doc map[string]interface{}
+		if err := toml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		return []interface{}{doc}, nil
+	}
+	return nil, fmt.Errorf("unsupported config format %s", ext)
+}
+
+type configEntry struct {
+	Key   string
+	Value string // "" for containers with no scalar children
+}
+
+// flattenConfig walks maps and lists. List indices are left out of the path,
+// so every item of "servers" contributes to "servers.host".
+func flattenConfig(prefix string, v interface{}, out *[]configEntry) {
+	if len(*out) >= MaxConfigEntries {
+		return
+	}
+
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			flattenConfig(join(k), val[k], out)
+		}
+		if len(val) == 0 && prefix != "" {
+			*out = append(*out, configEntry{Key: prefix})
+		}
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(val))
+		for k, child := range val {
+			converted[fmt.Sprint(k)] = child
+		}
+		flattenConfig(prefix, converted, out)
+	case []interface{}:
+		for _, item := range val {
+			flattenConfig(prefix, item, out)
+		}
+		if len(val) == 0 && prefix != "" {
+			*out = append(*out, configEntry{Key: prefix})
+		}
+	case []map[string]interface{}: // TOML arrays of tables
+		for _, item := range val {
+			flattenConfig(prefix, item, out)
+		}
+	case nil:
+		if prefix != "" {
+			*out = append(*out, configEntry{Key: prefix})
+		}
+	default:
+		if prefix == "" {
+			return // A bare scalar document has nothing to key on
+		}
+		value := fmt.Sprint(val)
+		if len(value) > 200 {
+			value = truncateUTF8(value, 200)
+		}
+		*out = append(*out, configEntry{Key: prefix, Value: value})
+	}
+}
@@ -0,0 +1,157 @@
+package core
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const MaxCSVReadSize = 1024 * 1024 // Enough rows to sample; exports can be gigabytes
+const csvSampleRows = 1000
+const csvSamplesPerColumn = 5
+const csvMaxColumns = 200
+
+// readCSVContent indexes the header as column names plus a few distinct
+// values per column, instead of the first 50 KB of raw rows.
+func readCSVContent(path string) Extraction {
+	res := newExtraction("")
+
+	f, err := os.Open(path)
+	if err != nil {
+		return res
+	}
+	defer f.Close()
+
+	buf := make([]byte, MaxCSVReadSize)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return res
+	}
+	data := buf[:n]
+	complete := n < MaxCSVReadSize
+	if !complete {
+		// Drop the partial last line
+		if i := bytes.LastIndexByte(data, '\n'); i > 0 {
+			data = data[:i]
+		}
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")) // Excel BOM
+
+	delim := detectDelimiter(data)
+	if strings.EqualFold(filepath.Ext(path), ".tsv") {
+		delim = '\t'
+	}
+
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = delim
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	r.ReuseRecord = true
+
+	header, err := r.Read()
+	if err != nil {
+		return res
+	}
+	var columns []string
+	for _, name := range header {
+		if len(columns) >= csvMaxColumns {
+			break
+		}
+		columns = append(columns, strings.TrimSpace(name))
+	}
+
+	samples := make([][]string, len(columns))
+	rows := 0
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			continue
+		}
+		rows++
+		if rows > csvSampleRows {
+			continue // Keep counting rows
+		}
+		for i, value := range record {
+			if i >= len(columns) || len(samples[i]) >= csvSamplesPerColumn {
+				continue
+			}
+			value = strings.TrimSpace(value)
+			if value == "" || len(value) > 60 || isNumeric(value) || containsString(samples[i], value) {
+				continue
+			}
+			samples[i] = append(samples[i], value)
+		}
+	}
+
+	var sb strings.Builder
+	var named []string
+	for _, c := range columns {
+		if c != "" {
+			named = append(named, c)
+			res.Metadata.Add("column", c)
+		}
+	}
+	sb.WriteString("Columns: " + strings.Join(named, ", ") + ".")
+
+	for i, c := range columns {
+		if c == "" || len(samples[i]) == 0 {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf(" %s: %s.", c, strings.Join(samples[i], ", ")))
+	}
+
+	if complete {
+		res.Metadata.Add("rows", strconv.Itoa(rows))
+	}
+	res.Text = cleanText(sb.String(), false)
+	return res
+}
+
+// detectDelimiter picks the candidate that splits the first lines into the
+// same number (>1) of fields most consistently.
+func detectDelimiter(data []byte) rune {
+	lines := strings.Split(string(data[:min(len(data), 64*1024)]), "\n")
+	if len(lines) > 20 {
+		lines = lines[:20]
+	}
+
+	best, bestScore := ',', 0
+	for _, d := range []rune{',', ';', '\t', '|'} {
+		header := strings.Count(lines[0], string(d))
+		if header == 0 {
+			continue
+		}
+		score := 0
+		for _, line := range lines {
+			if strings.Count(line, string(d)) == header {
+				score += header
+			}
+		}
+		if score > bestScore {
+			best, bestScore = d, score
+		}
+	}
+	return best
+}
+
+func isNumeric(s string) bool {
+	_, err := strconv.ParseFloat(strings.ReplaceAll(s, ",", ""), 64)
+	return err == nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
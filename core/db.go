@@ -1,18 +1,22 @@
 package core
 
 import (
+	"context"
 	"database/sql"
 	"encoding/binary"
 	"log"
 	"math"
 	"regexp"
+	"sort"
 	"strings"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
 var DB *sql.DB
-var queryCleaner = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+// Keeps letters in any script; \p{M} holds Devanagari vowel signs together with their word
+var queryCleaner = regexp.MustCompile(`[^\p{L}\p{M}\p{N}]+`)
 
 type SearchResult struct {
 	Path      string
@@ -20,14 +24,26 @@ type SearchResult struct {
 	Score     float32
 	IconData  string
 	Extension string
+	Location  string // Where the match is inside the file, e.g. "p. 12"
+	// The file this result belongs to, e.g. the video of a transcript
+	RelatedPath string
+	// Display name of virtual entries (calendar events, contacts)
+	Name string
+	// Matched a saved search after it was last opened
+	New bool
 }
 
+// unicode61 splits on combining marks by default, which breaks Devanagari words apart
+const ftsTokenizer = `"unicode61 categories 'L* M* N* Co'"`
+
 func InitDB(dbPath string) {
 	// Use AppData path to ensure write permissions
 	realPath := GetDataPath("index.db")
 
 	var err error
-	DB, err = sql.Open("sqlite3", realPath)
+	// Background backfills write while scans run: transactions take the write
+	// lock up front and wait for it, instead of failing when another writer commits
+	DB, err = sql.Open("sqlite3", realPath+"?_busy_timeout=5000&_txlock=immediate")
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -52,7 +68,7 @@ func InitDB(dbPath string) {
 	}
 
 	// Full Text Search (FTS5) virtual table
-	_, err = DB.Exec(`CREATE VIRTUAL TABLE IF NOT EXISTS files_fts USING fts5(filename, summary, path UNINDEXED, content='files', content_rowid='id');`)
+	_, err = DB.Exec(`CREATE VIRTUAL TABLE IF NOT EXISTS files_fts USING fts5(filename, summary, path UNINDEXED, content='files', content_rowid='id', tokenize=` + ftsTokenizer + `);`)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -89,7 +105,144 @@ func InitDB(dbPath string) {
 		log.Fatal(err)
 	}
 
+	// Structured fields from extractors (EXIF, tags, ...). One row per value.
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS file_metadata (
+		file_id INTEGER,
+		key TEXT,
+		value TEXT,
+		FOREIGN KEY(file_id) REFERENCES files(id) ON DELETE CASCADE
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+	DB.Exec(`CREATE INDEX IF NOT EXISTS idx_metadata_file ON file_metadata(file_id);`)
+	DB.Exec(`CREATE INDEX IF NOT EXISTS idx_metadata_key_value ON file_metadata(key, value COLLATE NOCASE);`)
+
+	// Page (etc.) boundaries inside a summary, used to locate chunks and snippets
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS file_segments (
+		file_id INTEGER,
+		start_offset INTEGER,
+		locator TEXT,
+		FOREIGN KEY(file_id) REFERENCES files(id) ON DELETE CASCADE
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+	DB.Exec(`CREATE INDEX IF NOT EXISTS idx_segments_file ON file_segments(file_id, start_offset);`)
+
+	// Note-to-note links ([[wiki]] and relative Markdown). target_id is NULL until resolved.
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS file_links (
+		source_id INTEGER,
+		target_id INTEGER,
+		target TEXT,
+		wiki INTEGER,
+		FOREIGN KEY(source_id) REFERENCES files(id) ON DELETE CASCADE
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+	DB.Exec(`CREATE INDEX IF NOT EXISTS idx_links_source ON file_links(source_id);`)
+	DB.Exec(`CREATE INDEX IF NOT EXISTS idx_links_target ON file_links(target_id);`)
+
+	// Typed values found in the text (email, url, phone, money, date, iban), normalized
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS file_entities (
+		file_id INTEGER,
+		type TEXT,
+		value TEXT,
+		FOREIGN KEY(file_id) REFERENCES files(id) ON DELETE CASCADE
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+	DB.Exec(`CREATE INDEX IF NOT EXISTS idx_entities_file ON file_entities(file_id);`)
+	DB.Exec(`CREATE INDEX IF NOT EXISTS idx_entities_value ON file_entities(type, value);`)
+
+	// Secrets found (and masked) in a file's text, for review
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS sensitive_flags (
+		file_id INTEGER,
+		kind TEXT,
+		count INTEGER,
+		FOREIGN KEY(file_id) REFERENCES files(id) ON DELETE CASCADE
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+	DB.Exec(`CREATE INDEX IF NOT EXISTS idx_sensitive_file ON sensitive_flags(file_id);`)
+
+	// Progress of one-off passes over the index, e.g. the highest file id checked for secrets
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS index_state (
+		key TEXT PRIMARY KEY,
+		value INTEGER
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	migrateSchema()
+	migrateFTSTokenizer()
 	setupTriggers()
+	invalidateBacklinks()
+	setupFuzzyIndex()
+	setupTermVocab()
+	setupUsageEvents()
+	setupSavedSearches()
+	go refreshInitials()
+}
+
+// migrateSchema adds columns introduced after the first release.
+// ALTER fails harmlessly when the column already exists.
+func migrateSchema() {
+	// The document's own date (e.g. EXIF date taken); date filters prefer it over mtime
+	DB.Exec(`ALTER TABLE files ADD COLUMN content_time INTEGER`)
+	// Where each chunk starts, so semantic hits can show their page
+	DB.Exec(`ALTER TABLE file_vectors ADD COLUMN start_offset INTEGER DEFAULT 0`)
+	DB.Exec(`ALTER TABLE file_vectors ADD COLUMN locator TEXT DEFAULT ''`)
+	DB.Exec(`ALTER TABLE file_segments ADD COLUMN is_code INTEGER DEFAULT 0`)
+	// Virtual entries (calendar events, contacts) point at the file they came from
+	DB.Exec(`ALTER TABLE files ADD COLUMN parent_id INTEGER`)
+	DB.Exec(`CREATE INDEX IF NOT EXISTS idx_files_parent ON files(parent_id)`)
+	// Detected language code (en, de, hi, ...); NULL = unknown
+	DB.Exec(`ALTER TABLE files ADD COLUMN lang TEXT`)
+	// Which embedding model produced a vector; only same-model vectors are comparable
+	DB.Exec(`ALTER TABLE file_vectors ADD COLUMN model TEXT DEFAULT 'minilm'`)
+	// Type sniffed from the file's magic bytes; NULL = not sniffed yet
+	DB.Exec(`ALTER TABLE files ADD COLUMN mime TEXT`)
+	// Bytes on disk, for size: filters; filled in by the quick scan
+	DB.Exec(`ALTER TABLE files ADD COLUMN size INTEGER`)
+	// Word initials of the filename ("vsc" for Visual Studio Code); NULL = not computed yet
+	DB.Exec(`ALTER TABLE files ADD COLUMN initials TEXT`)
+	DB.Exec(`CREATE INDEX IF NOT EXISTS idx_files_initials ON files(initials)`)
+	// Note links are resolved case-insensitively by path and by filename
+	DB.Exec(`CREATE INDEX IF NOT EXISTS idx_files_path_nocase ON files(path COLLATE NOCASE)`)
+	DB.Exec(`CREATE INDEX IF NOT EXISTS idx_files_filename_nocase ON files(filename COLLATE NOCASE)`)
+}
+
+// migrateFTSTokenizer recreates files_fts when it was built with the old
+// tokenizer, then rebuilds it from the files table.
+func migrateFTSTokenizer() {
+	var ddl string
+	if err := DB.QueryRow(`SELECT sql FROM sqlite_master WHERE name = 'files_fts'`).Scan(&ddl); err != nil {
+		return
+	}
+	if strings.Contains(ddl, "categories") {
+		return
+	}
+
+	log.Println("Rebuilding search index for Unicode tokenization...")
+	DB.Exec(`DROP TRIGGER IF EXISTS files_ai`)
+	DB.Exec(`DROP TRIGGER IF EXISTS files_ad`)
+	DB.Exec(`DROP TRIGGER IF EXISTS files_au`)
+	DB.Exec(`DROP TABLE files_fts`)
+	if _, err := DB.Exec(`CREATE VIRTUAL TABLE files_fts USING fts5(filename, summary, path UNINDEXED, content='files', content_rowid='id', tokenize=` + ftsTokenizer + `);`); err != nil {
+		log.Fatal(err)
+	}
+	DB.Exec(`INSERT INTO files_fts(files_fts) VALUES('rebuild')`)
 }
 
 func IncrementUsage(path string) {
@@ -104,24 +257,6 @@ func IncrementUsage(path string) {
 	}
 }
 
-func GetUsageMap() map[string]float32 {
-	rows, err := DB.Query("SELECT path, count FROM usage_stats")
-	if err != nil {
-		return map[string]float32{}
-	}
-	defer rows.Close()
-
-	usage := make(map[string]float32)
-	for rows.Next() {
-		var path string
-		var count int
-		if err := rows.Scan(&path, &count); err == nil {
-			usage[path] = float32(count)
-		}
-	}
-	return usage
-}
-
 func setupTriggers() {
 	// Automatically sync the FTS table when the main 'files' table changes
 	DB.Exec(`CREATE TRIGGER IF NOT EXISTS files_ai AFTER INSERT ON files BEGIN INSERT INTO files_fts(rowid, filename, summary, path) VALUES (new.id, new.filename, new.summary, new.path); END;`)
@@ -129,83 +264,218 @@ func setupTriggers() {
 	DB.Exec(`CREATE TRIGGER IF NOT EXISTS files_au AFTER UPDATE ON files BEGIN INSERT INTO files_fts(files_fts, rowid, filename, summary, path) VALUES('delete', old.id, old.filename, old.summary, old.path); INSERT INTO files_fts(rowid, filename, summary, path) VALUES (new.id, new.filename, new.summary, new.path); END;`)
 }
 
-func GetFilesNeedingEmbedding() (map[int]string, error) {
-	query := `SELECT id, summary FROM files WHERE summary IS NOT NULL AND summary != "" AND id NOT IN (SELECT distinct file_id FROM file_vectors)`
+// EmbeddingJob is a file whose summary still needs vectors.
+type EmbeddingJob struct {
+	Summary string
+	Lang    string
+}
+
+func GetFilesNeedingEmbedding() (map[int]EmbeddingJob, error) {
+	query := `SELECT id, summary, COALESCE(lang, '') FROM files WHERE summary IS NOT NULL AND summary != "" AND id NOT IN (SELECT distinct file_id FROM file_vectors)`
+	if CurrentSettings.SkipEmbeddingSensitive {
+		// Events and contacts of a flagged .ics / .vcf are skipped with it
+		query += ` AND id NOT IN (SELECT file_id FROM sensitive_flags) AND COALESCE(parent_id, 0) NOT IN (SELECT file_id FROM sensitive_flags)`
+	}
 	rows, err := DB.Query(query)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	results := make(map[int]string)
+	results := make(map[int]EmbeddingJob)
 	for rows.Next() {
 		var id int
-		var summary string
-		if err := rows.Scan(&id, &summary); err == nil {
-			results[id] = summary
+		var job EmbeddingJob
+		if err := rows.Scan(&id, &job.Summary, &job.Lang); err == nil {
+			results[id] = job
 		}
 	}
 	return results, nil
 }
 
-func SaveVector(fileId int, chunkIndex int, vector []float32) error {
+func SaveVector(fileId int, chunk Chunk, vector []float32, model string) error {
 	// Convert []float32 to byte slice for BLOB storage
 	byteBuf := make([]byte, len(vector)*4)
 	for i, v := range vector {
 		bits := math.Float32bits(v)
 		binary.LittleEndian.PutUint32(byteBuf[i*4:], bits)
 	}
-	_, err := DB.Exec(`INSERT INTO file_vectors (file_id, chunk_index, vector_blob) VALUES (?, ?, ?)`, fileId, chunkIndex, byteBuf)
+	_, err := DB.Exec(`INSERT INTO file_vectors (file_id, chunk_index, vector_blob, start_offset, locator, model) VALUES (?, ?, ?, ?, ?, ?)`,
+		fileId, chunk.Index, byteBuf, chunk.Start, chunk.Locator, model)
 	return err
 }
 
-func SearchFiles(queryText string, minTime int64, maxTime int64) ([]SearchResult, error) {
-	cleanQuery := queryCleaner.ReplaceAllString(queryText, " ")
-	terms := strings.Fields(cleanQuery)
-	if len(terms) == 0 {
+// SearchFiles runs boolean text (terms, "phrases", OR; see ParseQuery) against FTS
+// and returns up to limit best matches.
+func SearchFiles(ctx context.Context, queryText string, filters QueryFilters, limit int) ([]SearchResult, error) {
+	groups := parseTextGroups(queryText)
+	if len(groups) == 0 {
+		if filters.HasFields() {
+			return browseFiles(ctx, filters, limit)
+		}
 		return nil, nil
 	}
+	firstTerm := groups[0][0].Words[0]
+
+	noStem := func(w string) string { return w }
+	results, err := searchFTS(ctx, ftsExpression(groups, noStem), firstTerm, filters, "", nil, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// Stemmed variants only run against documents stored in that language
+	best := make(map[string]int, len(results))
+	for i, res := range results {
+		best[res.Path] = i
+	}
+	for _, lang := range []string{"en", "de", "hi"} {
+		stem := func(w string) string { return StemTerm(lang, w) }
+		stemmed := ftsExpression(groups, stem)
+		if stemmed == ftsExpression(groups, noStem) {
+			continue
+		}
+
+		extra, err := searchFTS(ctx, stemmed, stem(firstTerm), filters, " AND f.lang = ? ", []interface{}{lang}, limit)
+		if err != nil {
+			continue
+		}
+		for _, res := range extra {
+			if i, ok := best[res.Path]; ok {
+				if res.Score > results[i].Score {
+					results[i] = res
+				}
+				continue
+			}
+			best[res.Path] = len(results)
+			results = append(results, res)
+		}
+	}
 
-	var contentParts []string
-	for _, term := range terms {
-		contentParts = append(contentParts, term+"*")
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	if len(results) > limit {
+		results = results[:limit]
 	}
-	contentQuery := strings.Join(contentParts, " AND ")
+	return results, nil
+}
 
+func searchFTS(ctx context.Context, contentQuery, firstTerm string, filters QueryFilters, extraClause string, extraArgs []interface{}, limit int) ([]SearchResult, error) {
 	baseQuery := `
-		SELECT f.path, COALESCE(snippet(files_fts, 1, '[', ']', '...', 15), ''), COALESCE(f.icon_data, ''), f.extension, files_fts.rank
+		SELECT f.id, f.path, COALESCE(snippet(files_fts, 1, '[', ']', '...', 15), ''), COALESCE(f.icon_data, ''), f.extension, files_fts.rank
 		FROM files_fts 
 		JOIN files f ON f.id = files_fts.rowid
 		WHERE files_fts MATCH ? `
 
 	args := []interface{}{contentQuery}
 
-	if minTime > 0 {
-		baseQuery += " AND f.modified_time >= ? "
-		args = append(args, minTime)
-	}
-	if maxTime > 0 {
-		baseQuery += " AND f.modified_time <= ? "
-		args = append(args, maxTime)
-	}
+	clauses, clauseArgs := filters.sqlClauses()
+	baseQuery += clauses + extraClause
+	args = append(args, clauseArgs...)
+	args = append(args, extraArgs...)
 
-	baseQuery += " ORDER BY files_fts.rank LIMIT 50"
+	baseQuery += " ORDER BY files_fts.rank, f.path LIMIT ?"
+	args = append(args, limit)
 
-	rows, err := DB.Query(baseQuery, args...)
+	rows, err := DB.QueryContext(ctx, baseQuery, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	var results []SearchResult
+	var ids []int
 	for rows.Next() {
 		var res SearchResult
+		var id int
 		var rank float32
-		if err := rows.Scan(&res.Path, &res.Snippet, &res.IconData, &res.Extension, &rank); err != nil {
+		if err := rows.Scan(&id, &res.Path, &res.Snippet, &res.IconData, &res.Extension, &rank); err != nil {
 			return nil, err
 		}
 		// Invert rank (FTS returns negative for better matches) and scale
 		res.Score = float32(math.Abs(float64(rank))) * 1.5
 		results = append(results, res)
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	// Locations only for the rows that made the cut; summaries can be megabytes
+	for i, id := range ids {
+		results[i].Location = termLocator(ctx, id, firstTerm)
+	}
+	return results, nil
+}
+
+// termLocator returns the segment (page) containing the first occurrence of
+// term in a file's summary, or "" for files without segments.
+func termLocator(ctx context.Context, fileID int, term string) string {
+	rows, err := DB.QueryContext(ctx, "SELECT start_offset, locator FROM file_segments WHERE file_id = ? ORDER BY start_offset", fileID)
+	if err != nil {
+		return ""
+	}
+	var segments []Segment
+	for rows.Next() {
+		var seg Segment
+		if rows.Scan(&seg.Start, &seg.Locator) == nil {
+			segments = append(segments, seg)
+		}
+	}
+	rows.Close()
+	if len(segments) == 0 || term == "" {
+		return ""
+	}
+
+	var summary string
+	if DB.QueryRowContext(ctx, "SELECT COALESCE(summary, '') FROM files WHERE id = ?", fileID).Scan(&summary) != nil {
+		return ""
+	}
+	at := strings.Index(asciiLower(summary), asciiLower(term))
+	if at < 0 {
+		return ""
+	}
+	locator := ""
+	for _, seg := range segments {
+		if seg.Start > at {
+			break
+		}
+		locator = seg.Locator
+	}
+	return locator
+}
+
+// asciiLower lowercases A-Z only, so byte offsets stay those of the original text.
+func asciiLower(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if c >= 'A' && c <= 'Z' {
+			b[i] = c + 'a' - 'A'
+		}
+	}
+	return string(b)
+}
+
+// browseFiles answers filter-only queries (e.g. "artist:Radiohead"), newest first.
+func browseFiles(ctx context.Context, filters QueryFilters, limit int) ([]SearchResult, error) {
+	baseQuery := `
+		SELECT f.path, COALESCE(substr(f.summary, 1, 200), ''), COALESCE(f.icon_data, ''), f.extension
+		FROM files f
+		WHERE 1 = 1 `
+
+	clauses, args := filters.sqlClauses()
+	baseQuery += clauses + " ORDER BY COALESCE(f.content_time, f.modified_time) DESC, f.path LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := DB.QueryContext(ctx, baseQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []SearchResult
+	for rows.Next() {
+		var res SearchResult
+		if err := rows.Scan(&res.Path, &res.Snippet, &res.IconData, &res.Extension); err != nil {
+			return nil, err
+		}
+		res.Score = 1.0
+		results = append(results, res)
 	}
 	return results, nil
 }
@@ -0,0 +1,296 @@
+package core
+
+import (
+	"database/sql"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Entity is a typed value found in a file's text, stored normalized so
+// filters can match it exactly.
+type Entity struct {
+	Type  string // email, url, phone, money, date, iban
+	Value string
+}
+
+const MaxEntitiesPerFile = 1000
+
+var (
+	emailRegex = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	urlRegex   = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>"'\[\]{}|\\^]+`)
+	phoneRegex = regexp.MustCompile(`(?:\+|\b00)?\d{1,4}?[\s.-]?\(?\d{1,5}\)?(?:[\s.-]?\d{2,5}){1,4}\b`)
+	ibanRegex  = regexp.MustCompile(`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]{4}){2,7}(?: ?[A-Z0-9]{1,3})?\b`)
+
+	// $1,200.50 / € 99 / 1.234,56 EUR / 500 USD / Rs. 1,00,000
+	moneyRegex = regexp.MustCompile(`(?i)(?:([$€£¥₹]|\b(?:USD|EUR|GBP|INR|CHF|JPY|Rs\.?))\s?(\d[\d,.']*\d|\d)|(\d[\d,.']*\d|\d)\s?([$€£¥₹]|(?:USD|EUR|GBP|INR|CHF|JPY)\b))`)
+
+	isoDateRegex     = regexp.MustCompile(`\b(\d{4})-(\d{2})-(\d{2})\b`)
+	numericDateRegex = regexp.MustCompile(`\b(\d{1,2})([./])(\d{1,2})[./](\d{4})\b`)
+	textDateRegex    = regexp.MustCompile(`(?i)\b(?:(\d{1,2})\.?\s+(jan|feb|mar|apr|may|jun|jul|aug|sep|oct|nov|dec)[a-z]*\.?,?\s+(\d{4})|(jan|feb|mar|apr|may|jun|jul|aug|sep|oct|nov|dec)[a-z]*\.?\s+(\d{1,2})(?:st|nd|rd|th)?,?\s+(\d{4}))\b`)
+)
+
+var currencySymbols = map[string]string{
+	"$": "USD", "€": "EUR", "£": "GBP", "¥": "JPY", "₹": "INR", "RS": "INR", "RS.": "INR",
+}
+
+var monthNumbers = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+// ExtractEntities finds emails, URLs, IBANs, money amounts, dates and phone
+// numbers. Matching is purely pattern + checksum based, so it is repeatable.
+func ExtractEntities(text string) []Entity {
+	var entities []Entity
+	seen := make(map[Entity]bool)
+	add := func(typ, value string) {
+		e := Entity{Type: typ, Value: value}
+		if value == "" || seen[e] || len(entities) >= MaxEntitiesPerFile {
+			return
+		}
+		seen[e] = true
+		entities = append(entities, e)
+	}
+
+	// Spans already claimed by a more specific type; phone numbers are
+	// matched last and must not overlap them
+	var claimed [][]int
+	claim := func(loc []int) { claimed = append(claimed, loc) }
+
+	for _, loc := range emailRegex.FindAllStringIndex(text, -1) {
+		add("email", NormalizeEntity("email", text[loc[0]:loc[1]]))
+		claim(loc)
+	}
+	for _, loc := range urlRegex.FindAllStringIndex(text, -1) {
+		add("url", NormalizeEntity("url", text[loc[0]:loc[1]]))
+		claim(loc)
+	}
+	for _, loc := range ibanRegex.FindAllStringIndex(text, -1) {
+		if iban := NormalizeEntity("iban", text[loc[0]:loc[1]]); validIBAN(iban) {
+			add("iban", iban)
+		}
+		claim(loc) // A mistyped IBAN is still not a phone number
+	}
+	for _, loc := range moneyRegex.FindAllStringIndex(text, -1) {
+		if money := NormalizeEntity("money", text[loc[0]:loc[1]]); money != "" {
+			add("money", money)
+			claim(loc)
+		}
+	}
+	for _, re := range []*regexp.Regexp{isoDateRegex, numericDateRegex, textDateRegex} {
+		for _, loc := range re.FindAllStringIndex(text, -1) {
+			if date := NormalizeEntity("date", text[loc[0]:loc[1]]); date != "" {
+				add("date", date)
+			}
+			claim(loc)
+		}
+	}
+
+	for _, loc := range phoneRegex.FindAllStringIndex(text, -1) {
+		overlaps := false
+		for _, c := range claimed {
+			if loc[0] < c[1] && c[0] < loc[1] {
+				overlaps = true
+				break
+			}
+		}
+		if !overlaps {
+			add("phone", NormalizeEntity("phone", text[loc[0]:loc[1]]))
+		}
+	}
+	return entities
+}
+
+// NormalizeEntity brings a raw match (or a value typed in a filter) into the
+// stored form. Returns "" when the value is not a valid entity of that type.
+func NormalizeEntity(typ, raw string) string {
+	raw = strings.TrimSpace(raw)
+	switch typ {
+	case "email":
+		return strings.ToLower(raw)
+	case "url":
+		raw = strings.TrimRight(raw, ".,;:!?)")
+		if strings.HasPrefix(strings.ToLower(raw), "www.") {
+			raw = "http://" + raw
+		}
+		return raw
+	case "iban":
+		return strings.ToUpper(strings.ReplaceAll(raw, " ", ""))
+	case "phone":
+		return normalizePhone(raw)
+	case "money":
+		return normalizeMoney(raw)
+	case "date":
+		return normalizeDate(raw)
+	}
+	return raw
+}
+
+// normalizePhone keeps digits (and a leading +). 7-15 digits per E.164;
+// pure digit runs without separators are left alone (order numbers, years...).
+func normalizePhone(raw string) string {
+	var digits strings.Builder
+	for _, r := range raw {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	n := digits.Len()
+	if n < 7 || n > 15 {
+		return ""
+	}
+	if !strings.HasPrefix(raw, "+") && !strings.ContainsAny(raw, " -.()") {
+		return ""
+	}
+	if strings.HasPrefix(raw, "+") {
+		return "+" + digits.String()
+	}
+	if strings.HasPrefix(raw, "00") {
+		return "+" + strings.TrimPrefix(digits.String(), "00")
+	}
+	return digits.String()
+}
+
+// normalizeMoney renders "1.234,56 €" / "$1,234.56" as "1234.56 EUR" / "1234.56 USD".
+func normalizeMoney(raw string) string {
+	m := moneyRegex.FindStringSubmatch(raw)
+	if m == nil {
+		return ""
+	}
+	currency, amount := m[1], m[2]
+	if currency == "" {
+		amount, currency = m[3], m[4]
+	}
+	currency = strings.ToUpper(currency)
+	if code, ok := currencySymbols[currency]; ok {
+		currency = code
+	}
+
+	amount = strings.ReplaceAll(amount, "'", "")
+	// The last separator followed by 1-2 digits is the decimal point
+	lastSep := strings.LastIndexAny(amount, ".,")
+	if lastSep >= 0 && len(amount)-lastSep-1 <= 2 {
+		amount = strings.NewReplacer(".", "", ",", "").Replace(amount[:lastSep]) + "." + amount[lastSep+1:]
+	} else {
+		amount = strings.NewReplacer(".", "", ",", "").Replace(amount)
+	}
+
+	value, err := strconv.ParseFloat(amount, 64)
+	if err != nil {
+		return ""
+	}
+	return strconv.FormatFloat(value, 'f', 2, 64) + " " + currency
+}
+
+// normalizeDate renders any of the recognized date forms as YYYY-MM-DD.
+func normalizeDate(raw string) string {
+	var year, month, day int
+
+	if m := isoDateRegex.FindStringSubmatch(raw); m != nil {
+		year, _ = strconv.Atoi(m[1])
+		month, _ = strconv.Atoi(m[2])
+		day, _ = strconv.Atoi(m[3])
+	} else if m := numericDateRegex.FindStringSubmatch(raw); m != nil {
+		a, _ := strconv.Atoi(m[1])
+		b, _ := strconv.Atoi(m[3])
+		year, _ = strconv.Atoi(m[4])
+		// "05.03.2024" is day-first; "03/05/2024" is US month-first unless impossible
+		day, month = a, b
+		if m[2] == "/" && b <= 31 && a <= 12 {
+			day, month = b, a
+		}
+	} else if m := textDateRegex.FindStringSubmatch(raw); m != nil {
+		if m[1] != "" {
+			day, _ = strconv.Atoi(m[1])
+			month = monthNumbers[strings.ToLower(m[2])]
+			year, _ = strconv.Atoi(m[3])
+		} else {
+			month = monthNumbers[strings.ToLower(m[4])]
+			day, _ = strconv.Atoi(m[5])
+			year, _ = strconv.Atoi(m[6])
+		}
+	} else {
+		return ""
+	}
+
+	t := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if year < 1900 || year > 2200 || t.Day() != day || int(t.Month()) != month {
+		return "" // 31 February etc.
+	}
+	return t.Format("2006-01-02")
+}
+
+// validIBAN checks the length and the ISO 13616 mod-97 checksum.
+func validIBAN(iban string) bool {
+	if len(iban) < 15 || len(iban) > 34 {
+		return false
+	}
+	rearranged := iban[4:] + iban[:4]
+
+	var digits strings.Builder
+	for _, r := range rearranged {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r >= 'A' && r <= 'Z':
+			digits.WriteString(strconv.Itoa(int(r-'A') + 10))
+		default:
+			return false
+		}
+	}
+
+	n, ok := new(big.Int).SetString(digits.String(), 10)
+	if !ok {
+		return false
+	}
+	return new(big.Int).Mod(n, big.NewInt(97)).Int64() == 1
+}
+
+// --- DB HELPERS ---
+
+// SaveEntities replaces the stored entities of a file.
+func SaveEntities(tx *sql.Tx, fileID int, entities []Entity) error {
+	if _, err := tx.Exec("DELETE FROM file_entities WHERE file_id = ?", fileID); err != nil {
+		return err
+	}
+	if len(entities) == 0 {
+		return nil
+	}
+
+	stmt, err := tx.Prepare("INSERT INTO file_entities (file_id, type, value) VALUES (?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, e := range entities {
+		if _, err := stmt.Exec(fileID, e.Type, e.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetFileEntities lists the entities found in a file, grouped by type.
+func GetFileEntities(path string) []Entity {
+	entities := []Entity{}
+	rows, err := DB.Query(`
+		SELECT e.type, e.value FROM file_entities e
+		JOIN files f ON f.id = e.file_id
+		WHERE f.path = ? ORDER BY e.type, e.value`, path)
+	if err != nil {
+		return entities
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e Entity
+		if rows.Scan(&e.Type, &e.Value) == nil {
+			entities = append(entities, e)
+		}
+	}
+	return entities
+}
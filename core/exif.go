@@ -0,0 +1,439 @@
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// ExifData is the subset of EXIF tags we index for photos.
+type ExifData struct {
+	Make        string
+	Model       string
+	Lens        string
+	Software    string
+	Orientation int
+	DateTaken   time.Time
+
+	HasGPS    bool
+	Latitude  float64
+	Longitude float64
+}
+
+// EXIF tag IDs
+const (
+	tagMake              = 0x010F
+	tagModel             = 0x0110
+	tagOrientation       = 0x0112
+	tagSoftware          = 0x0131
+	tagDateTime          = 0x0132
+	tagExifIFD           = 0x8769
+	tagGPSIFD            = 0x8825
+	tagDateTimeOriginal  = 0x9003
+	tagDateTimeDigitized = 0x9004
+	tagOffsetTimeOrig    = 0x9011
+	tagLensMake          = 0xA433
+	tagLensModel         = 0xA434
+
+	tagGPSLatitudeRef  = 0x0001
+	tagGPSLatitude     = 0x0002
+	tagGPSLongitudeRef = 0x0003
+	tagGPSLongitude    = 0x0004
+)
+
+const maxExifSegment = 256 * 1024
+
+// ReadExif finds the EXIF block in a JPEG, TIFF, PNG (eXIf chunk) or WebP file.
+func ReadExif(path string) (*ExifData, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	head := make([]byte, 12)
+	if _, err := io.ReadFull(f, head); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case head[0] == 0xFF && head[1] == 0xD8:
+		block, err := findJpegExif(f)
+		if err != nil {
+			return nil, err
+		}
+		return parseTiff(bytes.NewReader(block), int64(len(block)))
+	case bytes.HasPrefix(head, []byte("II*\x00")) || bytes.HasPrefix(head, []byte("MM\x00*")):
+		// A TIFF file *is* an EXIF container; IFDs may live anywhere in it.
+		return parseTiff(f, info.Size())
+	case bytes.HasPrefix(head, []byte("\x89PNG\r\n\x1a\n")):
+		block, err := findPngExif(f)
+		if err != nil {
+			return nil, err
+		}
+		return parseTiff(bytes.NewReader(block), int64(len(block)))
+	case bytes.HasPrefix(head, []byte("RIFF")) && string(head[8:12]) == "WEBP":
+		block, err := findWebpExif(f)
+		if err != nil {
+			return nil, err
+		}
+		return parseTiff(bytes.NewReader(block), int64(len(block)))
+	}
+	return nil, fmt.Errorf("no exif container")
+}
+
+// --- CONTAINERS ---
+
+func findJpegExif(r io.ReadSeeker) ([]byte, error) {
+	if _, err := r.Seek(2, io.SeekStart); err != nil {
+		return nil, err
+	}
+	hdr := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(r, hdr); err != nil {
+			return nil, err
+		}
+		if hdr[0] != 0xFF {
+			return nil, fmt.Errorf("corrupt jpeg marker")
+		}
+		marker := hdr[1]
+		// Start of Scan: metadata segments are over
+		if marker == 0xDA || marker == 0xD9 {
+			return nil, fmt.Errorf("no exif segment")
+		}
+		length := int64(binary.BigEndian.Uint16(hdr[2:])) - 2
+		if length < 0 {
+			return nil, fmt.Errorf("corrupt jpeg segment")
+		}
+
+		if marker == 0xE1 && length > 6 && length <= maxExifSegment {
+			data := make([]byte, length)
+			if _, err := io.ReadFull(r, data); err != nil {
+				return nil, err
+			}
+			if bytes.HasPrefix(data, []byte("Exif\x00\x00")) {
+				return data[6:], nil
+			}
+			continue // XMP also lives in APP1
+		}
+		if _, err := r.Seek(length, io.SeekCurrent); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func findPngExif(r io.ReadSeeker) ([]byte, error) {
+	if _, err := r.Seek(8, io.SeekStart); err != nil {
+		return nil, err
+	}
+	hdr := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, hdr); err != nil {
+			return nil, err
+		}
+		length := int64(binary.BigEndian.Uint32(hdr[:4]))
+		kind := string(hdr[4:8])
+
+		switch kind {
+		case "eXIf":
+			if length > maxExifSegment {
+				return nil, fmt.Errorf("exif chunk too large")
+			}
+			data := make([]byte, length)
+			if _, err := io.ReadFull(r, data); err != nil {
+				return nil, err
+			}
+			return data, nil
+		case "IEND":
+			return nil, fmt.Errorf("no exif chunk")
+		}
+		// Skip data + CRC
+		if _, err := r.Seek(length+4, io.SeekCurrent); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func findWebpExif(r io.ReadSeeker) ([]byte, error) {
+	if _, err := r.Seek(12, io.SeekStart); err != nil {
+		return nil, err
+	}
+	hdr := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, hdr); err != nil {
+			return nil, err
+		}
+		length := int64(binary.LittleEndian.Uint32(hdr[4:]))
+		if string(hdr[:4]) == "EXIF" {
+			if length > maxExifSegment {
+				return nil, fmt.Errorf("exif chunk too large")
+			}
+			data := make([]byte, length)
+			if _, err := io.ReadFull(r, data); err != nil {
+				return nil, err
+			}
+			// Some encoders keep the JPEG-style prefix
+			return bytes.TrimPrefix(data, []byte("Exif\x00\x00")), nil
+		}
+		// Chunks are padded to even sizes
+		if _, err := r.Seek(length+length%2, io.SeekCurrent); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// --- TIFF / IFD PARSER ---
+
+type tiffReader struct {
+	r     io.ReaderAt
+	size  int64
+	order binary.ByteOrder
+}
+
+type ifdEntry struct {
+	Tag   uint16
+	Type  uint16
+	Count uint32
+	Raw   [4]byte // Inline value or offset
+}
+
+var tiffTypeSize = map[uint16]int64{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 7: 1, 9: 4, 10: 8}
+
+func parseTiff(r io.ReaderAt, size int64) (*ExifData, error) {
+	hdr := make([]byte, 8)
+	if _, err := r.ReadAt(hdr, 0); err != nil {
+		return nil, err
+	}
+
+	t := &tiffReader{r: r, size: size}
+	switch string(hdr[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("bad tiff byte order")
+	}
+	if t.order.Uint16(hdr[2:]) != 42 {
+		return nil, fmt.Errorf("bad tiff magic")
+	}
+
+	ifd0, err := t.readIFD(int64(t.order.Uint32(hdr[4:])))
+	if err != nil {
+		return nil, err
+	}
+
+	data := &ExifData{}
+	data.Make = t.ascii(ifd0[tagMake])
+	data.Model = t.ascii(ifd0[tagModel])
+	data.Software = t.ascii(ifd0[tagSoftware])
+	data.Orientation = int(t.uint(ifd0[tagOrientation]))
+	fallbackDate := t.ascii(ifd0[tagDateTime])
+
+	var exifIFD map[uint16]ifdEntry
+	if e, ok := ifd0[tagExifIFD]; ok {
+		exifIFD, _ = t.readIFD(int64(t.uint(e)))
+	}
+
+	dateStr := t.ascii(exifIFD[tagDateTimeOriginal])
+	if dateStr == "" {
+		dateStr = t.ascii(exifIFD[tagDateTimeDigitized])
+	}
+	if dateStr == "" {
+		dateStr = fallbackDate
+	}
+	data.DateTaken = parseExifTime(dateStr, t.ascii(exifIFD[tagOffsetTimeOrig]))
+
+	data.Lens = t.ascii(exifIFD[tagLensModel])
+	if lensMake := t.ascii(exifIFD[tagLensMake]); lensMake != "" && data.Lens != "" && !strings.HasPrefix(data.Lens, lensMake) {
+		data.Lens = lensMake + " " + data.Lens
+	}
+
+	if e, ok := ifd0[tagGPSIFD]; ok {
+		if gps, err := t.readIFD(int64(t.uint(e))); err == nil {
+			lat, okLat := t.degrees(gps[tagGPSLatitude])
+			lon, okLon := t.degrees(gps[tagGPSLongitude])
+			if okLat && okLon && !(lat == 0 && lon == 0) {
+				if strings.HasPrefix(t.ascii(gps[tagGPSLatitudeRef]), "S") {
+					lat = -lat
+				}
+				if strings.HasPrefix(t.ascii(gps[tagGPSLongitudeRef]), "W") {
+					lon = -lon
+				}
+				data.HasGPS = true
+				data.Latitude = lat
+				data.Longitude = lon
+			}
+		}
+	}
+
+	return data, nil
+}
+
+func (t *tiffReader) readIFD(offset int64) (map[uint16]ifdEntry, error) {
+	if offset <= 0 || offset+2 > t.size {
+		return nil, fmt.Errorf("ifd offset out of range")
+	}
+	buf := make([]byte, 2)
+	if _, err := t.r.ReadAt(buf, offset); err != nil {
+		return nil, err
+	}
+	count := int64(t.order.Uint16(buf))
+	if count > 1000 || offset+2+count*12 > t.size {
+		return nil, fmt.Errorf("corrupt ifd")
+	}
+
+	raw := make([]byte, count*12)
+	if _, err := t.r.ReadAt(raw, offset+2); err != nil {
+		return nil, err
+	}
+
+	entries := make(map[uint16]ifdEntry, count)
+	for i := int64(0); i < count; i++ {
+		b := raw[i*12 : (i+1)*12]
+		var e ifdEntry
+		e.Tag = t.order.Uint16(b[0:])
+		e.Type = t.order.Uint16(b[2:])
+		e.Count = t.order.Uint32(b[4:])
+		copy(e.Raw[:], b[8:12])
+		entries[e.Tag] = e
+	}
+	return entries, nil
+}
+
+// value returns the raw bytes of an entry, following the offset if needed.
+func (t *tiffReader) value(e ifdEntry) []byte {
+	unit, ok := tiffTypeSize[e.Type]
+	if !ok || e.Count == 0 {
+		return nil
+	}
+	total := unit * int64(e.Count)
+	if total <= 4 {
+		return e.Raw[:total]
+	}
+	if total > maxExifSegment {
+		return nil
+	}
+	offset := int64(t.order.Uint32(e.Raw[:]))
+	if offset+total > t.size {
+		return nil
+	}
+	buf := make([]byte, total)
+	if _, err := t.r.ReadAt(buf, offset); err != nil {
+		return nil
+	}
+	return buf
+}
+
+func (t *tiffReader) ascii(e ifdEntry) string {
+	if e.Type != 2 {
+		return ""
+	}
+	v := t.value(e)
+	if i := bytes.IndexByte(v, 0); i >= 0 {
+		v = v[:i]
+	}
+	return strings.TrimSpace(string(v))
+}
+
+func (t *tiffReader) uint(e ifdEntry) uint32 {
+	v := t.value(e)
+	switch {
+	case e.Type == 3 && len(v) >= 2:
+		return uint32(t.order.Uint16(v))
+	case (e.Type == 4 || e.Type == 9) && len(v) >= 4:
+		return t.order.Uint32(v)
+	}
+	return 0
+}
+
+// degrees converts a GPS (deg, min, sec) rational triplet.
+func (t *tiffReader) degrees(e ifdEntry) (float64, bool) {
+	v := t.value(e)
+	if e.Type != 5 || len(v) < 24 {
+		return 0, false
+	}
+	var parts [3]float64
+	for i := range parts {
+		num := float64(t.order.Uint32(v[i*8:]))
+		den := float64(t.order.Uint32(v[i*8+4:]))
+		if den == 0 {
+			return 0, false
+		}
+		parts[i] = num / den
+	}
+	return parts[0] + parts[1]/60 + parts[2]/3600, true
+}
+
+func parseExifTime(value, offset string) time.Time {
+	if value == "" || strings.HasPrefix(value, "0000") {
+		return time.Time{}
+	}
+	if offset != "" {
+		if t, err := time.Parse("2006:01:02 15:04:05-07:00", value+offset); err == nil {
+			return t
+		}
+	}
+	// EXIF stores camera-local time without a zone
+	t, err := time.ParseInLocation("2006:01:02 15:04:05", value, time.Local)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// --- INDEXING ---
+
+func (d *ExifData) Camera() string {
+	if d.Model == "" {
+		return d.Make
+	}
+	// Most vendors already repeat the make in the model ("Canon EOS R5")
+	if d.Make == "" || strings.HasPrefix(strings.ToLower(d.Model), strings.ToLower(strings.Fields(d.Make)[0])) {
+		return d.Model
+	}
+	return d.Make + " " + d.Model
+}
+
+// addTo stores the EXIF fields as metadata and searchable text.
+func (d *ExifData) addTo(ext *Extraction) {
+	var desc []string
+
+	if cam := d.Camera(); cam != "" {
+		ext.Metadata.Add("camera", cam)
+		ext.Metadata.Add("camera_make", d.Make)
+		ext.Metadata.Add("camera_model", d.Model)
+		desc = append(desc, "Camera: "+cam)
+	}
+	if d.Lens != "" {
+		ext.Metadata.Add("lens", d.Lens)
+		desc = append(desc, "Lens: "+d.Lens)
+	}
+	if d.Software != "" {
+		ext.Metadata.Add("software", d.Software)
+	}
+	if d.Orientation > 0 {
+		ext.Metadata.Add("orientation", fmt.Sprint(d.Orientation))
+	}
+	if !d.DateTaken.IsZero() {
+		ext.Metadata.Add("date_taken", d.DateTaken.Format(time.RFC3339))
+		ext.ContentTime = d.DateTaken.Unix()
+		desc = append(desc, "Taken: "+d.DateTaken.Format("2 January 2006"))
+	}
+	if d.HasGPS {
+		coords := fmt.Sprintf("%.6f,%.6f", d.Latitude, d.Longitude)
+		ext.Metadata.Add("gps", coords)
+		desc = append(desc, "GPS: "+coords)
+	}
+
+	ext.appendText(strings.Join(desc, " "))
+}
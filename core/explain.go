@@ -0,0 +1,83 @@
+package core
+
+import (
+	"context"
+	"time"
+)
+
+// SourceScore is one source's part in a result's fused score.
+type SourceScore struct {
+	Rank         int     // 1-based position in that source's list
+	Score        float32 // The source's own score: FTS bm25, cosine, edit similarity, decayed opens
+	Contribution float32 // weight / (k + Rank)
+}
+
+// ScoreComponents record how one result got its place in the ranking.
+type ScoreComponents struct {
+	Path string
+
+	// keyword, semantic, fuzzy, acronym, affinity, usage; missing = not found by that source
+	Sources map[string]SourceScore
+
+	// Best semantic chunk and where it is (page, cell, timestamp)
+	MatchedChunk  string
+	ChunkLocation string
+
+	Fused      float32 // Sum of the source contributions
+	Boosts     FeatureBoosts
+	FinalScore float32 // Fused * boosts
+}
+
+// SearchExplanation is a ranked page together with how the query was read.
+type SearchExplanation struct {
+	Query   string
+	Text    string // Boolean text sent to FTS
+	Plain   string // Words embedded for semantic search
+	Filters QueryFilters
+
+	// Date range from natural language or modified: (local time), empty = unbounded
+	DateFrom string
+	DateTo   string
+
+	Ranking RankingSettings
+	Results []ScoreComponents
+}
+
+// ExplainSearch runs the query like HybridSearch and reports the score
+// components behind each result of the first page.
+func ExplainSearch(ctx context.Context, rawQuery string) (SearchExplanation, error) {
+	parsed := ParseQuery(rawQuery)
+	explanation := SearchExplanation{
+		Query:   rawQuery,
+		Text:    parsed.Text,
+		Plain:   parsed.Plain,
+		Filters: parsed.Filters,
+		Ranking: CurrentSettings.Ranking,
+	}
+	minTime, maxTime := parsed.Filters.MinTime, parsed.Filters.MaxTime
+	for _, ff := range parsed.Filters.Fields {
+		if ff.Key == "modified" && !ff.Negate {
+			times, _ := parseDateRange(ff.Value)
+			minTime, maxTime = 0, 0
+			if times.HasMin {
+				minTime = times.Min
+			}
+			if times.HasMax {
+				maxTime = times.Max
+			}
+		}
+	}
+	if minTime > 0 {
+		explanation.DateFrom = time.Unix(minTime, 0).Format("2006-01-02 15:04")
+	}
+	if maxTime > 0 {
+		explanation.DateTo = time.Unix(maxTime, 0).Format("2006-01-02 15:04")
+	}
+
+	page, err := HybridSearch(ctx, rawQuery, SearchOptions{Explain: true})
+	if err != nil {
+		return explanation, err
+	}
+	explanation.Results = page.Explain
+	return explanation, nil
+}
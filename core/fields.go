@@ -0,0 +1,277 @@
+package core
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FieldFilter restricts results to files whose metadata Key matches Value.
+// A trailing '*' on the value turns it into a prefix match; Negate ("-key:value")
+// keeps only files that do not match.
+type FieldFilter struct {
+	Key    string
+	Value  string
+	Negate bool
+}
+
+// QueryFilters holds everything that narrows a search besides the free text.
+type QueryFilters struct {
+	MinTime int64
+	MaxTime int64
+	Fields  []FieldFilter
+
+	// Media length in seconds, 0 = unbounded
+	MinDuration float64
+	MaxDuration float64
+
+	// Terms and "phrases" the file must not contain (-draft)
+	Exclude []string
+}
+
+// filterKeys maps the prefixes users can type (artist:...) to metadata keys.
+// Unknown prefixes stay in the query as plain text, so "C:\" or "http:" are safe.
+var filterKeys = map[string]string{
+	"artist": "artist",
+	"album":  "album",
+	"title":  "title",
+	"genre":  "genre",
+	"year":   "year",
+	"camera": "camera",
+	"lens":   "lens",
+
+	"resolution": "resolution",
+	"codec":      "codec",
+
+	"author":  "author",
+	"subject": "subject",
+	"keyword": "keyword",
+	"column":  "column",
+	"key":     "key",
+	"tag":     "tag",
+
+	"lang": "lang", // files.lang, not metadata
+	"kind": "kind", // files.mime / extension, see kindClause
+
+	// Columns of files: ext:pdf, path:Projects, size:>10MB, modified:2024-01..2024-03
+	"ext":      "ext",
+	"path":     "path",
+	"size":     "size",
+	"modified": "modified",
+
+	"attendee":     "attendee",
+	"location":     "location",
+	"organization": "organization",
+
+	// file_entities, not metadata (see entityFilterKeys)
+	"email": "email",
+	"url":   "url",
+	"phone": "phone",
+	"iban":  "iban",
+	"money": "money",
+	"date":  "date",
+}
+
+// entityFilterKeys are matched against extracted entities instead of metadata
+// (date: also matches front-matter dates).
+var entityFilterKeys = map[string]bool{
+	"email": true, "url": true, "phone": true, "iban": true, "money": true, "date": true,
+}
+
+// Spellings of the same resolution bucket (see ResolutionLabel)
+var resolutionAliases = map[string]string{
+	"4320p": "8K", "8k": "8K",
+	"2160p": "4K", "4k": "4K", "uhd": "4K",
+	"1440p": "1440p", "qhd": "1440p",
+	"1080p": "1080p", "fhd": "1080p",
+	"720p": "720p", "hd": "720p",
+	"480p": "480p", "sd": "SD",
+}
+
+var fieldTokenRegex = regexp.MustCompile(`(?i)(^|\s)(-?)([a-z_]+):("[^"]*"|\S+)`)
+
+// ParseFieldFilters pulls "key:value" / key:"some value" / -key:value / #tag tokens out of the query.
+func ParseFieldFilters(query string) (string, []FieldFilter) {
+	var filters []FieldFilter
+
+	clean := fieldTokenRegex.ReplaceAllStringFunc(query, func(token string) string {
+		m := fieldTokenRegex.FindStringSubmatch(token)
+		key, ok := filterKeys[strings.ToLower(m[3])]
+		if !ok {
+			return token
+		}
+		value := strings.Trim(m[4], `"`)
+		if value == "" {
+			return token
+		}
+		// Malformed ranges stay in the query as text
+		if _, ok := parseSizeRange(value); key == "size" && !ok {
+			return token
+		}
+		if _, ok := parseDateRange(value); key == "modified" && !ok {
+			return token
+		}
+		if key == "lang" {
+			value = normalizeLang(value)
+		}
+		if entityFilterKeys[key] {
+			if normalized := NormalizeEntity(key, value); normalized != "" {
+				value = normalized
+			}
+		}
+		if key == "resolution" {
+			if label, ok := resolutionAliases[strings.ToLower(value)]; ok {
+				value = label
+			}
+		}
+		filters = append(filters, FieldFilter{Key: key, Value: value, Negate: m[2] == "-"})
+		return m[1]
+	})
+
+	// "#project" is shorthand for tag:project
+	clean = hashtagRegex.ReplaceAllStringFunc(clean, func(token string) string {
+		m := hashtagRegex.FindStringSubmatch(token)
+		filters = append(filters, FieldFilter{Key: "tag", Value: m[1]})
+		return " "
+	})
+
+	return strings.Join(strings.Fields(clean), " "), filters
+}
+
+var durationPhraseRegex = regexp.MustCompile(`(?i)\b(longer than|more than|over|at least|shorter than|less than|under|at most)\s+(\d+(?:\.\d+)?)\s*(hours?|hrs?|h|minutes?|mins?|m|seconds?|secs?|s)\b`)
+
+// ParseDurationFilter turns "longer than 10 minutes" / "under 1 hour" into bounds.
+func ParseDurationFilter(query string) (string, float64, float64) {
+	var minDur, maxDur float64
+
+	clean := durationPhraseRegex.ReplaceAllStringFunc(query, func(phrase string) string {
+		m := durationPhraseRegex.FindStringSubmatch(phrase)
+		n, err := strconv.ParseFloat(m[2], 64)
+		if err != nil {
+			return phrase
+		}
+		switch unit := strings.ToLower(m[3]); {
+		case strings.HasPrefix(unit, "h"):
+			n *= 3600
+		case strings.HasPrefix(unit, "m"):
+			n *= 60
+		}
+
+		switch strings.ToLower(m[1]) {
+		case "shorter than", "less than", "under", "at most":
+			maxDur = n
+		default:
+			minDur = n
+		}
+		return ""
+	})
+
+	return strings.Join(strings.Fields(clean), " "), minDur, maxDur
+}
+
+// HasFields reports whether any non-date filter is set; those allow text-less queries.
+func (q QueryFilters) HasFields() bool {
+	return len(q.Fields) > 0 || q.MinDuration > 0 || q.MaxDuration > 0 || len(q.Exclude) > 0
+}
+
+// sqlClauses renders the filters as extra WHERE conditions on the files alias "f".
+func (q QueryFilters) sqlClauses() (string, []interface{}) {
+	var sb strings.Builder
+	var args []interface{}
+
+	if q.MinTime > 0 {
+		sb.WriteString(" AND COALESCE(f.content_time, f.modified_time) >= ? ")
+		args = append(args, q.MinTime)
+	}
+	if q.MaxTime > 0 {
+		sb.WriteString(" AND COALESCE(f.content_time, f.modified_time) <= ? ")
+		args = append(args, q.MaxTime)
+	}
+
+	for _, ff := range q.Fields {
+		cond, condArgs := ff.condition()
+		if ff.Negate {
+			sb.WriteString(" AND NOT (" + cond + ") ")
+		} else {
+			sb.WriteString(" AND " + cond + " ")
+		}
+		args = append(args, condArgs...)
+	}
+
+	for _, term := range q.Exclude {
+		if expr := ftsExpression(parseTextGroups(term), func(w string) string { return w }); expr != "" {
+			sb.WriteString(" AND f.id NOT IN (SELECT rowid FROM files_fts WHERE files_fts MATCH ?) ")
+			args = append(args, expr)
+		}
+	}
+
+	if q.MinDuration > 0 {
+		sb.WriteString(" AND f.id IN (SELECT file_id FROM file_metadata WHERE key = 'duration' AND CAST(value AS REAL) > ?) ")
+		args = append(args, q.MinDuration)
+	}
+	if q.MaxDuration > 0 {
+		sb.WriteString(" AND f.id IN (SELECT file_id FROM file_metadata WHERE key = 'duration' AND CAST(value AS REAL) < ?) ")
+		args = append(args, q.MaxDuration)
+	}
+
+	return sb.String(), args
+}
+
+// condition renders one filter as a SQL condition on the files alias "f".
+func (ff FieldFilter) condition() (string, []interface{}) {
+	switch {
+	case ff.Key == "lang":
+		return "COALESCE(f.lang, '') = ?", []interface{}{ff.Value}
+	case ff.Key == "kind":
+		return kindClause(ff.Value)
+	case ff.Key == "ext":
+		// ext:pdf or ext:pdf,docx
+		var exts []interface{}
+		for _, e := range strings.Split(strings.ToLower(ff.Value), ",") {
+			if e = strings.TrimPrefix(strings.TrimSpace(e), "."); e != "" {
+				exts = append(exts, "."+e)
+			}
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(exts)), ",")
+		return "lower(f.extension) IN (" + placeholders + ")", exts
+	case ff.Key == "path":
+		return `f.path LIKE ? ESCAPE '\'`, []interface{}{"%" + likeEscape(strings.TrimSuffix(ff.Value, "*")) + "%"}
+	case ff.Key == "size":
+		sizes, _ := parseSizeRange(ff.Value)
+		return rangeCondition("f.size", sizes)
+	case ff.Key == "modified":
+		times, _ := parseDateRange(ff.Value)
+		return rangeCondition("f.modified_time", times)
+	case ff.Key == "date":
+		// Dates found in the text, or a note's own front-matter date
+		return "(f.id IN (SELECT file_id FROM file_entities WHERE type = 'date' AND value = ?) OR f.id IN (SELECT file_id FROM file_metadata WHERE key = 'date' AND value = ?))", []interface{}{ff.Value, ff.Value}
+	case entityFilterKeys[ff.Key]:
+		return "f.id IN (SELECT file_id FROM file_entities WHERE type = ? AND value = ? COLLATE NOCASE)", []interface{}{ff.Key, ff.Value}
+	case ff.Key == "key" && !strings.HasSuffix(ff.Value, "*"):
+		// Config key paths: "key:max_connections" also finds "postgres.max_connections"
+		return `f.id IN (SELECT file_id FROM file_metadata WHERE key = 'key' AND (value = ? COLLATE NOCASE OR value LIKE ? ESCAPE '\'))`, []interface{}{ff.Value, "%." + likeEscape(ff.Value)}
+	case strings.HasSuffix(ff.Value, "*"):
+		return `f.id IN (SELECT file_id FROM file_metadata WHERE key = ? AND value LIKE ? ESCAPE '\')`, []interface{}{ff.Key, likeEscape(strings.TrimSuffix(ff.Value, "*")) + "%"}
+	}
+	return "f.id IN (SELECT file_id FROM file_metadata WHERE key = ? AND value = ? COLLATE NOCASE)", []interface{}{ff.Key, ff.Value}
+}
+
+// likeEscape makes % and _ in user input literal for LIKE ... ESCAPE '\'.
+func likeEscape(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// rangeCondition renders inclusive bounds on a column.
+func rangeCondition(column string, r valueRange) (string, []interface{}) {
+	switch {
+	case r.HasMin && r.HasMax:
+		return "(" + column + " BETWEEN ? AND ?)", []interface{}{r.Min, r.Max}
+	case r.HasMax:
+		return column + " <= ?", []interface{}{r.Max}
+	case r.HasMin:
+		return column + " >= ?", []interface{}{r.Min}
+	}
+	return "1 = 1", nil
+}
@@ -0,0 +1,217 @@
+package core
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"unicode"
+)
+
+const (
+	FuzzyMinHits     = 5   // Fuzzy matching only fills in when keyword hits are fewer
+	fuzzyCandidates  = 300 // Trigram hits reranked by edit distance
+	fuzzyMinTermLen  = 4   // Shorter terms are too ambiguous to correct; they must match exactly
+	fuzzyMaxResults  = 20
+	fuzzyPrefixSlack = 0.1 // Matching only the start of a word scores slightly lower
+)
+
+// Set once the trigram index is filled; FuzzySearch stays off until then
+var fuzzyReady atomic.Bool
+
+// setupFuzzyIndex creates the trigram index over filenames and its sync
+// triggers. SQLite builds without the trigram tokenizer just go without fuzzy
+// matching; the triggers are only added once the table exists. Filling a new
+// index runs in the background (resumed on the next start if interrupted).
+func setupFuzzyIndex() {
+	fuzzyReady.Store(false)
+	var name string
+	if DB.QueryRow(`SELECT name FROM sqlite_master WHERE name = 'files_trigram'`).Scan(&name) != nil {
+		if _, err := DB.Exec(`CREATE VIRTUAL TABLE files_trigram USING fts5(filename, content='files', content_rowid='id', tokenize='trigram');`); err != nil {
+			log.Printf("Fuzzy filename index unavailable: %v", err)
+			return
+		}
+		DB.Exec(`INSERT OR REPLACE INTO index_state (key, value) VALUES ('fuzzy_pending', 1)`)
+	}
+
+	DB.Exec(`CREATE TRIGGER IF NOT EXISTS files_tri_ai AFTER INSERT ON files BEGIN INSERT INTO files_trigram(rowid, filename) VALUES (new.id, new.filename); END;`)
+	DB.Exec(`CREATE TRIGGER IF NOT EXISTS files_tri_ad AFTER DELETE ON files BEGIN INSERT INTO files_trigram(files_trigram, rowid, filename) VALUES('delete', old.id, old.filename); END;`)
+	DB.Exec(`CREATE TRIGGER IF NOT EXISTS files_tri_au AFTER UPDATE OF filename ON files BEGIN INSERT INTO files_trigram(files_trigram, rowid, filename) VALUES('delete', old.id, old.filename); INSERT INTO files_trigram(rowid, filename) VALUES (new.id, new.filename); END;`)
+
+	var pending int
+	if DB.QueryRow(`SELECT value FROM index_state WHERE key = 'fuzzy_pending'`).Scan(&pending) != nil || pending == 0 {
+		fuzzyReady.Store(true)
+		return
+	}
+	go buildFuzzyIndex()
+}
+
+// buildFuzzyIndex fills the trigram index from the files table.
+func buildFuzzyIndex() {
+	log.Println("Building fuzzy filename index...")
+	if _, err := DB.Exec(`INSERT INTO files_trigram(files_trigram) VALUES('rebuild')`); err != nil {
+		log.Printf("Fuzzy filename index build failed: %v", err)
+		return
+	}
+	DB.Exec(`DELETE FROM index_state WHERE key = 'fuzzy_pending'`)
+	fuzzyReady.Store(true)
+	log.Println("Fuzzy filename index ready.")
+}
+
+// FuzzySearch finds files whose names are a few typos away from the terms
+// ("chrme" -> chrome.lnk, "invocie" -> Invoice-2024.pdf). Every term has to
+// match some word of the filename.
+func FuzzySearch(ctx context.Context, terms []string, filters QueryFilters) ([]SearchResult, error) {
+	if !fuzzyReady.Load() {
+		return nil, nil
+	}
+	var trigrams []string
+	seen := make(map[string]bool)
+	var fuzzyTerms [][]rune
+	for _, term := range terms {
+		runes := []rune(strings.ToLower(term))
+		fuzzyTerms = append(fuzzyTerms, runes)
+		if len(runes) < fuzzyMinTermLen {
+			continue
+		}
+		for i := 0; i+3 <= len(runes); i++ {
+			if g := string(runes[i : i+3]); !seen[g] {
+				seen[g] = true
+				trigrams = append(trigrams, `"`+strings.ReplaceAll(g, `"`, `""`)+`"`)
+			}
+		}
+	}
+	if len(trigrams) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		SELECT f.path, f.filename, COALESCE(f.icon_data, ''), f.extension
+		FROM files_trigram
+		JOIN files f ON f.id = files_trigram.rowid
+		WHERE files_trigram MATCH ? `
+	args := []interface{}{strings.Join(trigrams, " OR ")}
+	clauses, clauseArgs := filters.sqlClauses()
+	query += clauses + " ORDER BY files_trigram.rank LIMIT ?"
+	args = append(append(args, clauseArgs...), fuzzyCandidates)
+
+	rows, err := DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []SearchResult
+	for rows.Next() {
+		var res SearchResult
+		var filename string
+		if err := rows.Scan(&res.Path, &filename, &res.IconData, &res.Extension); err != nil {
+			continue
+		}
+		if score := fuzzyNameScore(fuzzyTerms, filename); score > 0 {
+			res.Score = score
+			results = append(results, res)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	if len(results) > fuzzyMaxResults {
+		results = results[:fuzzyMaxResults]
+	}
+	return results, nil
+}
+
+// fuzzyNameScore is the mean similarity (0..1] of each term to its closest
+// filename word, or 0 when a term is too far from every word.
+func fuzzyNameScore(terms [][]rune, filename string) float32 {
+	base := strings.TrimSuffix(filename, extOf(filename))
+	words := strings.FieldsFunc(strings.ToLower(base), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	if len(words) == 0 {
+		return 0
+	}
+
+	var total float32
+	for _, term := range terms {
+		best := float32(0)
+		allowed := maxEdits(len(term))
+		for _, w := range words {
+			word := []rune(w)
+			sim := float32(0)
+			if d := osaDistance(term, word); d <= allowed {
+				sim = 1 - float32(d)/float32(len(term))
+			}
+			// "chrom" is still being typed: compare against the word's start
+			if len(word) > len(term) {
+				if d := osaDistance(term, word[:len(term)]); d <= allowed {
+					sim = max(sim, 1-float32(d)/float32(len(term))-fuzzyPrefixSlack)
+				}
+			}
+			best = max(best, sim)
+		}
+		if best <= 0 {
+			return 0
+		}
+		total += best
+	}
+	return total / float32(len(terms))
+}
+
+func maxEdits(n int) int {
+	switch {
+	case n < fuzzyMinTermLen:
+		return 0
+	case n >= 8:
+		return 2
+	}
+	return 1
+}
+
+// extOf is filepath.Ext without treating a leading dot (".bashrc") as an extension.
+func extOf(name string) string {
+	if i := strings.LastIndexByte(name, '.'); i > 0 {
+		return name[i:]
+	}
+	return ""
+}
+
+// osaDistance is the optimal string alignment distance: Levenshtein plus
+// adjacent transpositions, the most common typo ("invocie").
+func osaDistance(a, b []rune) int {
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(b)]
+}
+
+// fuzzyTerms picks the loose words of a boolean query; OR groups and phrases
+// are left to FTS.
+func fuzzyTerms(text string) []string {
+	var terms []string
+	for _, group := range parseTextGroups(text) {
+		if len(group) != 1 || group[0].Phrase {
+			return nil
+		}
+		terms = append(terms, group[0].Words...)
+	}
+	return terms
+}
@@ -1,129 +1,271 @@
 package core
 
 import (
-	"path/filepath"
+	"context"
+	"encoding/base64"
+	"fmt"
 	"sort"
+	"strconv"
 	"strings"
-	"sync"
 )
 
 const (
-	WeightVector  = 1.0 // Semantic meaning importance
-	WeightKeyword = 1.2 // Exact word match importance
+	DefaultPageSize = 15
+	MaxPageSize     = 100
+
+	keywordDepth  = 50 // Candidates fetched per source for the first pages
+	semanticDepth = 10
 )
 
-func HybridSearch(rawQuery string) ([]SearchResult, error) {
-	// 1. NLP Date Parsing
-	cleanQuery, minTime, maxTime := ParseDateQuery(rawQuery)
+// SearchOptions selects a page of results. A Cursor from the previous page
+// takes precedence over Offset.
+type SearchOptions struct {
+	Offset int    `json:"offset"`
+	Limit  int    `json:"limit"`
+	Cursor string `json:"cursor"`
 
-	var wg sync.WaitGroup
+	// Progress, if set, receives the keyword and filename matches before the
+	// slower semantic results are merged in
+	Progress func(SearchPage) `json:"-"`
 
-	// Declare variables before goroutines
-	var vectorResults []SearchResult
-	var keywordResults []SearchResult
-	var errVector, errKeyword error
+	// Explain fills SearchPage.Explain with the score components of each result
+	Explain bool `json:"-"`
+}
 
-	// 2. Parallel Search
-	wg.Add(2) // Add both at once for clarity
+// SearchPage is one page of ranked results.
+type SearchPage struct {
+	Results    []SearchResult
+	Total      int    // Ranked candidates across all pages
+	Estimated  bool   // Total is a lower bound: a source had more hits than it returned
+	NextCursor string // Empty on the last page
 
-	go func() {
-		defer wg.Done()
-		if IsAIReady {
-			vectorResults, errVector = SemanticSearch(cleanQuery, minTime, maxTime)
-		}
-	}()
+	Explain []ScoreComponents `json:",omitempty"` // Parallel to Results, only with SearchOptions.Explain
+}
+
+// pageCursor marks the last result of a page. Pages continue after that
+// (score, path) position, so results never repeat or shift between pages.
+type pageCursor struct {
+	Offset int
+	Score  float32
+	Path   string
+}
+
+func (c pageCursor) encode() string {
+	raw := fmt.Sprintf("%d|%s|%s", c.Offset, strconv.FormatFloat(float64(c.Score), 'g', -1, 32), c.Path)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(s string) (pageCursor, bool) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return pageCursor{}, false
+	}
+	parts := strings.SplitN(string(raw), "|", 3)
+	if len(parts) != 3 {
+		return pageCursor{}, false
+	}
+	offset, err1 := strconv.Atoi(parts[0])
+	score, err2 := strconv.ParseFloat(parts[1], 32)
+	if err1 != nil || err2 != nil {
+		return pageCursor{}, false
+	}
+	return pageCursor{Offset: offset, Score: float32(score), Path: parts[2]}, true
+}
+
+// HybridSearch merges keyword, semantic, typo-tolerant and abbreviation
+// matches into one ranking and returns the requested page of it. It stops
+// early with ctx.Err() once ctx is cancelled (the user kept typing).
+func HybridSearch(ctx context.Context, rawQuery string, opts SearchOptions) (SearchPage, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	limit = min(limit, MaxPageSize)
+	offset := max(opts.Offset, 0)
+	cursor, hasCursor := decodeCursor(opts.Cursor)
+	if hasCursor {
+		offset = cursor.Offset
+	}
+
+	// Later pages need deeper candidate lists from every source
+	keywordLimit := max(keywordDepth, offset+limit+1)
+	semanticLimit := max(semanticDepth, offset+limit+1)
+
+	// 1. Field Filters (artist:..., ext:..., -draft), durations, NLP dates, phrases and OR
+	parsed := ParseQuery(rawQuery)
+	cleanQuery, filters := parsed.Plain, parsed.Filters
+	weights := CurrentSettings.Ranking
+	k := weights.k()
 
+	// 2. Semantic search runs in the background; keyword and filename matches are fast
+	var vectorResults []SearchResult
+	var errVector error
+	semanticRunning := IsAIReady && cleanQuery != "" && weights.SemanticWeight > 0
+	semanticDone := make(chan struct{})
 	go func() {
-		defer wg.Done()
-		keywordResults, errKeyword = SearchFiles(cleanQuery, minTime, maxTime)
+		defer close(semanticDone)
+		if semanticRunning {
+			vectorResults, errVector = SemanticSearch(ctx, cleanQuery, filters, semanticLimit)
+		}
 	}()
 
-	wg.Wait()
+	keywordResults, errKeyword := SearchFiles(ctx, parsed.Text, filters, keywordLimit)
 
-	// Return early if both searches failed
-	if errVector != nil && errKeyword != nil {
-		return nil, errKeyword // Or return a combined error
+	// Few exact hits: the query may be misspelled ("chrme", "invocie")
+	var fuzzyResults []SearchResult
+	if len(keywordResults) < FuzzyMinHits {
+		fuzzyResults, _ = FuzzySearch(ctx, fuzzyTerms(parsed.Text), filters)
 	}
 
-	// 3. Load Usage Stats (Fast RAM lookup)
-	usageMap := GetUsageMap()
-
-	// 4. Merge & Rank
-	type MergedResult struct {
-		Result     SearchResult
-		FinalScore float32
+	// A single short word may be an abbreviation ("vsc", "gc", "hs")
+	var acronymResults []SearchResult
+	if terms := fuzzyTerms(parsed.Text); len(terms) == 1 {
+		acronymResults, _ = AcronymSearch(ctx, terms[0], filters)
 	}
-	scoreMap := make(map[string]MergedResult)
 
-	// Helper to apply boosts
-	calculateBoost := func(path string, baseScore float32) float32 {
-		score := baseScore
+	// 3. Load decayed usage and note backlinks; files often picked for this query join the candidates
+	usage := LoadUsageScores(rawQuery, weights.halfLifeDays())
+	backlinkMap := GetBacklinkCounts()
+	var affinityResults []SearchResult
+	if weights.AffinityWeight > 0 {
+		affinityResults = AffinityResults(ctx, usage.Affinity, filters)
+	}
 
-		// A. Frequency Boost
-		if count, ok := usageMap[path]; ok {
-			score = score * (1.0 + (float32(count) * 0.5))
+	// 4. Merge & Rank (once with the fast sources, again when semantic results arrive)
+	rank := func(vectorResults []SearchResult) SearchPage {
+		type MergedResult struct {
+			Result     SearchResult
+			FinalScore float32
+			Why        ScoreComponents
 		}
+		scoreMap := make(map[string]*MergedResult)
 
-		// B. App Type Boost
-		lower := strings.ToLower(path)
-		if strings.HasSuffix(lower, ".lnk") || strings.HasSuffix(lower, ".exe") {
-			score *= 2.0
+		// Reciprocal Rank Fusion: each source adds weight/(k+rank), so FTS ranks,
+		// cosine similarities and edit distances never have to share a scale
+		for _, source := range []struct {
+			Name    string
+			Results []SearchResult
+			Weight  float64
+		}{
+			{"keyword", keywordResults, weights.KeywordWeight},
+			{"semantic", vectorResults, weights.SemanticWeight},
+			{"fuzzy", fuzzyResults, weights.FuzzyWeight},
+			{"acronym", acronymResults, weights.AcronymWeight},
+			{"affinity", affinityResults, weights.AffinityWeight},
+		} {
+			if source.Weight <= 0 {
+				continue
+			}
+			for i, res := range source.Results {
+				contribution := float32(source.Weight / (k + float64(i+1)))
+				existing, found := scoreMap[res.Path]
+				if !found {
+					existing = &MergedResult{Result: res, Why: ScoreComponents{Path: res.Path, Sources: map[string]SourceScore{}}}
+					scoreMap[res.Path] = existing
+				}
+				existing.FinalScore += contribution
+				existing.Why.Sources[source.Name] = SourceScore{Rank: i + 1, Score: res.Score, Contribution: contribution}
+				if source.Name == "semantic" {
+					existing.Why.MatchedChunk, existing.Why.ChunkLocation = res.Snippet, res.Location
+				}
+				if !found {
+					continue
+				}
+				if len(res.Snippet) > len(existing.Result.Snippet) {
+					existing.Result.Snippet = res.Snippet
+				}
+				if existing.Result.Location == "" {
+					existing.Result.Location = res.Location
+				}
+			}
 		}
 
-		// C. Exact Name Match Boost
-		name := filepath.Base(lower)
-		if strings.HasPrefix(name, strings.ToLower(cleanQuery)) {
-			score *= 1.5
+		// Frecency is one more ranked list, over the files that matched
+		used := make([]*MergedResult, 0)
+		for path, mr := range scoreMap {
+			if usage.Frecency[path] > 0 {
+				used = append(used, mr)
+			}
+		}
+		sort.Slice(used, func(i, j int) bool {
+			if fi, fj := usage.Frecency[used[i].Result.Path], usage.Frecency[used[j].Result.Path]; fi != fj {
+				return fi > fj
+			}
+			return used[i].Result.Path < used[j].Result.Path
+		})
+		for i, mr := range used {
+			if weights.UsageWeight <= 0 {
+				break
+			}
+			contribution := float32(weights.UsageWeight / (k + float64(i+1)))
+			mr.FinalScore += contribution
+			mr.Why.Sources["usage"] = SourceScore{Rank: i + 1, Score: usage.Frecency[mr.Result.Path], Contribution: contribution}
 		}
 
-		return score
-	}
+		// Boost features (apps, name prefix, backlinks) scale the fused score
+		finalResults := make([]MergedResult, 0, len(scoreMap))
+		for path, mr := range scoreMap {
+			mr.Why.Fused = mr.FinalScore
+			mr.Why.Boosts = weights.featureBoosts(path, cleanQuery, backlinkMap[path])
+			mr.FinalScore *= mr.Why.Boosts.product()
+			mr.Why.FinalScore = mr.FinalScore
+			finalResults = append(finalResults, *mr)
+		}
 
-	// Process Keyword Results
-	for _, res := range keywordResults {
-		scoreMap[res.Path] = MergedResult{
-			Result:     res,
-			FinalScore: calculateBoost(res.Path, res.Score*WeightKeyword),
+		// Ties are broken by path so the order is the same on every page request
+		after := func(a, b MergedResult) bool {
+			if a.FinalScore != b.FinalScore {
+				return a.FinalScore > b.FinalScore
+			}
+			return a.Result.Path < b.Result.Path
 		}
-	}
+		sort.Slice(finalResults, func(i, j int) bool { return after(finalResults[i], finalResults[j]) })
 
-	// Merge Vector Results
-	for _, res := range vectorResults {
-		if existing, found := scoreMap[res.Path]; found {
-			// Found in both: "Perfect Match" bonus
-			newScore := calculateBoost(res.Path, res.Score*WeightVector)
-			existing.FinalScore += newScore + 0.5
+		start := min(offset, len(finalResults))
+		if hasCursor {
+			last := MergedResult{Result: SearchResult{Path: cursor.Path}, FinalScore: cursor.Score}
+			start = sort.Search(len(finalResults), func(i int) bool { return after(last, finalResults[i]) })
+		}
+		end := min(start+limit, len(finalResults))
 
-			if len(res.Snippet) > len(existing.Result.Snippet) {
-				existing.Result.Snippet = res.Snippet
+		page := SearchPage{
+			Results:   make([]SearchResult, 0, end-start),
+			Total:     len(finalResults),
+			Estimated: len(keywordResults) >= keywordLimit || len(vectorResults) >= semanticLimit,
+		}
+		for _, mr := range finalResults[start:end] {
+			mr.Result.RelatedPath = RelatedPath(mr.Result.Path)
+			if strings.Contains(mr.Result.Path, VirtualSeparator) {
+				mr.Result.Name = EntryName(mr.Result.Path)
 			}
-			scoreMap[res.Path] = existing
-		} else {
-			scoreMap[res.Path] = MergedResult{
-				Result:     res,
-				FinalScore: calculateBoost(res.Path, res.Score*WeightVector),
+			page.Results = append(page.Results, mr.Result)
+			if opts.Explain {
+				page.Explain = append(page.Explain, mr.Why)
 			}
 		}
+		if end < len(finalResults) || (page.Estimated && end > start) {
+			lastResult := finalResults[end-1]
+			page.NextCursor = pageCursor{Offset: end, Score: lastResult.FinalScore, Path: lastResult.Result.Path}.encode()
+		}
+		return page
 	}
 
-	// Sort by FinalScore (descending)
-	finalResults := make([]MergedResult, 0, len(scoreMap))
-	for _, v := range scoreMap {
-		finalResults = append(finalResults, v)
-	}
-
-	sort.Slice(finalResults, func(i, j int) bool {
-		return finalResults[i].FinalScore > finalResults[j].FinalScore
-	})
-
-	// Return top 15 results
-	output := make([]SearchResult, 0, 15)
-	for i, mr := range finalResults {
-		if i >= 15 {
-			break
+	// 5. Show the fast results while the embeddings are still being compared
+	if opts.Progress != nil && semanticRunning && ctx.Err() == nil {
+		select {
+		case <-semanticDone:
+		default:
+			opts.Progress(rank(nil))
 		}
-		output = append(output, mr.Result)
 	}
+	<-semanticDone
 
-	return output, nil
+	if err := ctx.Err(); err != nil {
+		return SearchPage{}, err
+	}
+	// Return early if both searches failed
+	if errVector != nil && errKeyword != nil {
+		return SearchPage{}, errKeyword // Or return a combined error
+	}
+	return rank(vectorResults), nil
 }
@@ -0,0 +1,210 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const MaxPimSize = 20 * 1024 * 1024 // Full calendar / address book exports
+const MaxSubEntries = 5000
+
+// contentLine is one unfolded iCalendar/vCard property: NAME;PARAM=x:VALUE
+type contentLine struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// readContentLines unfolds continuation lines (RFC 5545 §3.1) and splits
+// each line into name, parameters and value.
+func readContentLines(path string) []contentLine {
+	stat, err := os.Stat(path)
+	if err != nil || stat.Size() > MaxPimSize {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	var raw []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(raw) > 0 {
+			raw[len(raw)-1] += line[1:]
+			continue
+		}
+		// vCard 2.1 quoted-printable soft line breaks end in "="
+		if len(raw) > 0 && strings.HasSuffix(raw[len(raw)-1], "=") && strings.Contains(strings.ToUpper(raw[len(raw)-1]), "QUOTED-PRINTABLE") {
+			raw[len(raw)-1] = strings.TrimSuffix(raw[len(raw)-1], "=") + line
+			continue
+		}
+		raw = append(raw, line)
+	}
+
+	var lines []contentLine
+	for _, line := range raw {
+		colon := contentLineColon(line)
+		if colon < 0 {
+			continue
+		}
+		parts := strings.Split(line[:colon], ";")
+		name := strings.ToUpper(parts[0])
+		if dot := strings.LastIndexByte(name, '.'); dot >= 0 {
+			name = name[dot+1:] // vCard groups: "item1.EMAIL"
+		}
+
+		params := map[string]string{}
+		for _, p := range parts[1:] {
+			if eq := strings.IndexByte(p, '='); eq >= 0 {
+				params[strings.ToUpper(p[:eq])] = strings.Trim(p[eq+1:], `"`)
+			} else {
+				params["TYPE"] = p // vCard 2.1 bare types: TEL;CELL:...
+			}
+		}
+		lines = append(lines, contentLine{Name: name, Params: params, Value: line[colon+1:]})
+	}
+	return lines
+}
+
+// contentLineColon finds the name/value separator, skipping colons inside quoted params.
+func contentLineColon(line string) int {
+	quoted := false
+	for i, r := range line {
+		switch r {
+		case '"':
+			quoted = !quoted
+		case ':':
+			if !quoted {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// unescapeText reverses RFC 5545 TEXT escaping.
+func unescapeText(s string) string {
+	return strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(s)
+}
+
+// parseICalTime reads DATE-TIME ("20240305T100000Z", with optional TZID) and DATE values.
+func parseICalTime(line contentLine) time.Time {
+	value := strings.TrimSpace(line.Value)
+
+	loc := time.Local
+	if tzid := line.Params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		if t, err := time.Parse("20060102T150405Z", value); err == nil {
+			return t
+		}
+	}
+	for _, layout := range []string{"20060102T150405", "20060102T1504", "20060102"} {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// entryKey names a sub-entry by its UID, so its path survives entries being
+// added or removed around it. Entries without a UID (or repeating one) fall
+// back to their position.
+func entryKey(kind, uid string, position int, used map[string]bool) string {
+	key := fmt.Sprintf("%s/%d", kind, position)
+	if uid = strings.TrimSpace(uid); uid != "" && !used[kind+"/"+uid] {
+		key = kind + "/" + uid
+	}
+	for used[key] {
+		key += "+"
+	}
+	used[key] = true
+	return key
+}
+
+// readICalContent turns every VEVENT into a sub-entry; the file itself only
+// gets a short description.
+func readICalContent(path string) Extraction {
+	res := newExtraction("")
+
+	var event *SubEntry
+	var uid, recurrence, calendarName string
+	used := make(map[string]bool)
+	for _, line := range readContentLines(path) {
+		switch line.Name {
+		case "BEGIN":
+			if strings.EqualFold(line.Value, "VEVENT") && len(res.Entries) < MaxSubEntries {
+				event = &SubEntry{Metadata: Metadata{}}
+				uid, recurrence = "", ""
+			}
+			continue
+		case "END":
+			if strings.EqualFold(line.Value, "VEVENT") && event != nil {
+				// Moved occurrences of a recurring event share its UID
+				if uid != "" && recurrence != "" {
+					uid += "/" + recurrence
+				}
+				event.Key = entryKey("event", uid, len(res.Entries)+1, used)
+				event.finish()
+				res.Entries = append(res.Entries, *event)
+				event = nil
+			}
+			continue
+		case "X-WR-CALNAME":
+			calendarName = unescapeText(line.Value)
+		}
+		if event == nil {
+			continue
+		}
+
+		switch line.Name {
+		case "UID":
+			uid = strings.TrimSpace(line.Value)
+		case "RECURRENCE-ID":
+			recurrence = strings.TrimSpace(line.Value)
+		case "SUMMARY":
+			event.Name = unescapeText(line.Value)
+			event.addText(event.Name)
+		case "LOCATION":
+			location := unescapeText(line.Value)
+			event.Metadata.Add("location", location)
+			event.addText("Location: " + location)
+		case "DESCRIPTION":
+			event.addText(unescapeText(line.Value))
+		case "ATTENDEE", "ORGANIZER":
+			name := line.Params["CN"]
+			email := strings.TrimPrefix(strings.TrimPrefix(line.Value, "mailto:"), "MAILTO:")
+			for _, v := range []string{name, email} {
+				event.Metadata.Add("attendee", v)
+			}
+			event.addText(strings.TrimSpace(name + " " + email))
+		case "DTSTART":
+			if t := parseICalTime(line); !t.IsZero() {
+				event.Metadata.Add("start", t.Format(time.RFC3339))
+				event.ContentTime = t.Unix()
+				event.addText("Starts: " + t.Format("Monday 2 January 2006 15:04"))
+			}
+		case "DTEND":
+			if t := parseICalTime(line); !t.IsZero() {
+				event.Metadata.Add("end", t.Format(time.RFC3339))
+			}
+		}
+	}
+
+	res.Metadata.Add("title", calendarName)
+	res.Metadata.Add("events", fmt.Sprint(len(res.Entries)))
+	res.Text = strings.TrimSpace(fmt.Sprintf("Calendar %s with %d events", calendarName, len(res.Entries)))
+	return res
+}
@@ -1,7 +1,7 @@
 package core
 
 import (
-	"bytes"
+	"database/sql"
 	"fmt"
 	"io"
 	"io/fs"
@@ -10,8 +10,9 @@ import (
 	"regexp"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
-	"github.com/ledongthuc/pdf"
 	"github.com/nguyenthenguyen/docx"
 )
 
@@ -40,8 +41,8 @@ func cleanText(raw string, isXml bool) string {
 	lastWasSpace := true
 
 	for _, r := range raw {
-		// Allow standard text + Newlines
-		isValid := (r >= 32 && r <= 126) || r == '\n' || r == '\t'
+		// Allow printable text in any script + Newlines (not U+FFFD from broken UTF-8)
+		isValid := (unicode.IsPrint(r) && r != utf8.RuneError) || r == '\n' || r == '\t'
 
 		if isValid {
 			b.WriteRune(r)
@@ -62,43 +63,14 @@ func cleanText(raw string, isXml bool) string {
 func isContentReadable(ext string) bool {
 	e := strings.ToLower(ext)
 	switch e {
-	case ".txt", ".rtf", ".pdf", ".docx", ".jpg", ".jpeg", ".png", ".webp":
+	case ".txt", ".md", ".markdown", ".rtf", ".pdf", ".docx", ".ipynb", ".csv", ".tsv", ".json", ".yaml", ".yml", ".toml", ".srt", ".vtt", ".ics", ".vcf", ".jpg", ".jpeg", ".png", ".webp", ".tif", ".tiff":
 		return true
 	}
-	return false
+	return isAudioFile(e) || isParsableVideo(e)
 }
 
 // --- PARSERS ---
 
-func readPdfContent(path string) string {
-	defer func() { recover() }()
-	f, r, err := pdf.Open(path)
-	if err != nil {
-		return ""
-	}
-	defer f.Close()
-
-	var buf bytes.Buffer
-	limit := 5
-	if r.NumPage() < limit {
-		limit = r.NumPage()
-	}
-
-	for i := 1; i <= limit; i++ {
-		p := r.Page(i)
-		if p.V.IsNull() {
-			continue
-		}
-		text, _ := p.GetPlainText(nil)
-		buf.WriteString(text + " ")
-		if buf.Len() > MaxReadSize {
-			break
-		}
-	}
-	// PDF extractor returns Plain Text, so isXml = false
-	return cleanText(buf.String(), false)
-}
-
 func readDocxContent(path string) string {
 	defer func() { recover() }()
 	r, err := docx.ReadDocxFile(path)
@@ -137,36 +109,87 @@ func readTextContent(path string) string {
 	return cleanText(raw, isXmlOrHtml)
 }
 
+// readImageContent combines vision tags/OCR with the photo's EXIF fields.
+func readImageContent(path string) Extraction {
+	text, err := AnalyzeImage(path)
+	if err != nil {
+		text = ""
+	}
+	res := newExtraction(text)
+
+	if exif, err := ReadExif(path); err == nil {
+		exif.addTo(&res)
+	}
+	return res
+}
+
+// readAudioContent indexes ID3 / Vorbis / MP4 tags. The filename is already in FTS.
+func readAudioContent(path, ext string) Extraction {
+	res := newExtraction("")
+	if tags, err := readAudioTagsAs(path, ext); err == nil {
+		tags.addTo(&res)
+	}
+	return res
+}
+
+// readVideoContent indexes container metadata (duration, resolution, codec, title).
+func readVideoContent(path, ext string) Extraction {
+	res := newExtraction("")
+	if info, err := readVideoInfoAs(path, ext); err == nil {
+		info.addTo(&res)
+	}
+	return res
+}
+
 // --- SAFE RUNNER ---
-func getContentWithTimeout(path string) string {
-	resultChan := make(chan string, 1)
+// ext selects the extractor; it is the file's extension or the one implied by its sniffed type.
+func getContentWithTimeout(path, ext string) Extraction {
+	resultChan := make(chan Extraction, 1)
 
 	go func() {
-		ext := strings.ToLower(filepath.Ext(path))
-		var res string
-		var err error
+		var res Extraction
 
 		switch ext {
 		case ".pdf":
 			res = readPdfContent(path)
 		case ".docx":
-			res = readDocxContent(path)
-		case ".jpg", ".jpeg", ".png", ".webp": // Add this block
-			res, err = AnalyzeImage(path)
-			if err != nil {
-				res = ""
-			}
+			res = newExtraction(readDocxContent(path))
+		case ".ipynb":
+			res = readNotebookContent(path)
+		case ".csv", ".tsv":
+			res = readCSVContent(path)
+		case ".json", ".yaml", ".yml", ".toml":
+			res = readConfigContent(path)
+		case ".md", ".markdown":
+			res = readMarkdownContent(path)
+		case ".srt", ".vtt":
+			res = readSubtitleContent(path)
+		case ".ics":
+			res = readICalContent(path)
+		case ".vcf":
+			res = readVCardContent(path)
+		case ".jpg", ".jpeg", ".png", ".webp", ".tif", ".tiff":
+			res = readImageContent(path)
+		case ".mp3", ".flac", ".ogg", ".oga", ".opus", ".m4a":
+			res = readAudioContent(path, ext)
+		case ".mp4", ".m4v", ".mov", ".mkv", ".webm":
+			res = readVideoContent(path, ext)
 		default:
-			res = readTextContent(path)
+			res = newExtraction(readTextContent(path))
 		}
 		resultChan <- res
 	}()
 
+	timeout := FileTimeout
+	if ext == ".pdf" {
+		timeout = PdfTimeout
+	}
+
 	select {
 	case res := <-resultChan:
 		return res
-	case <-time.After(FileTimeout):
-		return ""
+	case <-time.After(timeout):
+		return newExtraction("")
 	}
 }
 
@@ -194,6 +217,23 @@ func LoadFileMap(driveRoot string) (map[string]int64, error) {
 	return fileMap, nil
 }
 
+// loadUnsizedPaths lists files indexed before sizes were recorded (backfilled once).
+func loadUnsizedPaths(driveRoot string) map[string]bool {
+	unsized := make(map[string]bool)
+	rows, err := DB.Query("SELECT path FROM files WHERE size IS NULL AND parent_id IS NULL AND path LIKE ?", driveRoot+"%")
+	if err != nil {
+		return unsized
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var path string
+		if rows.Scan(&path) == nil {
+			unsized[path] = true
+		}
+	}
+	return unsized
+}
+
 // --- PHASE 1: QUICK SCAN ---
 func RunQuickScan(root string) {
 	fmt.Printf("\n>>> PHASE 1: Quick Scan (Filenames) on %s\n", root)
@@ -204,16 +244,19 @@ func RunQuickScan(root string) {
 	if err != nil {
 		return
 	}
+	unsized := loadUnsizedPaths(root)
 
 	tx, err := DB.Begin()
 	if err != nil {
 		return
 	}
 
-	insertStmt, _ := tx.Prepare(`INSERT INTO files (path, filename, extension, modified_time, summary) VALUES (?, ?, ?, ?, NULL)`)
-	updateStmt, _ := tx.Prepare(`UPDATE files SET modified_time = ?, summary = NULL WHERE path = ?`)
+	insertStmt, _ := tx.Prepare(`INSERT INTO files (path, filename, extension, modified_time, size, summary, initials) VALUES (?, ?, ?, ?, ?, NULL, ?)`)
+	updateStmt, _ := tx.Prepare(`UPDATE files SET modified_time = ?, size = ?, summary = NULL, mime = NULL WHERE path = ?`)
 	defer insertStmt.Close()
 	defer updateStmt.Close()
+	sizeStmt, _ := tx.Prepare(`UPDATE files SET size = ? WHERE path = ?`)
+	defer sizeStmt.Close()
 
 	batchSize := 2000
 
@@ -257,14 +300,17 @@ func RunQuickScan(root string) {
 
 		if exists {
 			if storedModTime == currentModTime {
+				if unsized[path] {
+					sizeStmt.Exec(info.Size(), path)
+				}
 				stats.Skipped++
 				delete(existingFiles, path)
 				return nil
 			}
-			_, err = updateStmt.Exec(currentModTime, path)
+			_, err = updateStmt.Exec(currentModTime, info.Size(), path)
 			stats.Updated++
 		} else {
-			_, err = insertStmt.Exec(path, d.Name(), filepath.Ext(d.Name()), currentModTime)
+			_, err = insertStmt.Exec(path, d.Name(), filepath.Ext(d.Name()), currentModTime, info.Size(), nameInitials(d.Name()))
 			stats.Added++
 		}
 
@@ -273,8 +319,9 @@ func RunQuickScan(root string) {
 		if (stats.Added+stats.Updated)%batchSize == 0 && (stats.Added+stats.Updated) > 0 {
 			tx.Commit()
 			tx, _ = DB.Begin()
-			insertStmt, _ = tx.Prepare(`INSERT INTO files (path, filename, extension, modified_time, summary) VALUES (?, ?, ?, ?, NULL)`)
-			updateStmt, _ = tx.Prepare(`UPDATE files SET modified_time = ?, summary = NULL WHERE path = ?`)
+			insertStmt, _ = tx.Prepare(`INSERT INTO files (path, filename, extension, modified_time, size, summary, initials) VALUES (?, ?, ?, ?, ?, NULL, ?)`)
+			updateStmt, _ = tx.Prepare(`UPDATE files SET modified_time = ?, size = ?, summary = NULL, mime = NULL WHERE path = ?`)
+			sizeStmt, _ = tx.Prepare(`UPDATE files SET size = ? WHERE path = ?`)
 			fmt.Printf("\r[QuickScan] Scanned: %d | New: %d | Upd: %d", stats.Scanned, stats.Added, stats.Updated)
 		}
 		return nil
@@ -288,85 +335,224 @@ func RunQuickScan(root string) {
 func RunDeepScan() {
 	fmt.Println("\n>>> PHASE 2: Deep Scan (Content Extraction)")
 	startTime := time.Now()
+	RedactStoredSummaries()
 	processedCount := 0
 
-	rows, err := DB.Query("SELECT path, filename FROM files WHERE summary IS NULL")
+	rows, err := DB.Query("SELECT id, path, filename, modified_time, mime FROM files WHERE summary IS NULL")
 	if err != nil {
 		fmt.Printf("Error querying: %v\n", err)
 		return
 	}
 	defer rows.Close()
 
-	var pendingFiles []string
+	type pendingFile struct {
+		ID      int
+		Path    string
+		ModTime int64
+		Ext     string // Extractor to use; decided after sniffing when Sniff is set
+		Mime    sql.NullString
+		Sniff   bool
+	}
+	var pendingFiles []pendingFile
 	for rows.Next() {
-		var path, name string
-		rows.Scan(&path, &name)
-		if isContentReadable(filepath.Ext(name)) {
-			pendingFiles = append(pendingFiles, path)
+		var file pendingFile
+		var name string
+		rows.Scan(&file.ID, &file.Path, &name, &file.ModTime, &file.Mime)
+
+		// Unknown extensions are sniffed once, in the loop below; the type is
+		// kept so files we still can't read are not reopened on every scan
+		ext := strings.ToLower(filepath.Ext(name))
+		file.Sniff = !isContentReadable(ext) && !file.Mime.Valid && !noSniffExtensions[ext]
+		file.Ext = extractorExt(ext, file.Mime.String)
+		if file.Sniff || isContentReadable(file.Ext) {
+			pendingFiles = append(pendingFiles, file)
 		}
 	}
 	rows.Close()
 
 	total := len(pendingFiles)
-	fmt.Printf("Found %d files needing content extraction.\n", total)
+	fmt.Printf("Found %d files needing content extraction or type sniffing.\n", total)
 	if total == 0 {
 		return
 	}
 
+	updateQuery := "UPDATE files SET summary = ?, content_time = ?, lang = ?, mime = ? WHERE id = ?"
+	detectors := sensitiveDetectors()
 	tx, _ := DB.Begin()
-	updateStmt, _ := tx.Prepare("UPDATE files SET summary = ? WHERE path = ?")
+	updateStmt, _ := tx.Prepare(updateQuery)
 	defer updateStmt.Close()
 
-	for _, path := range pendingFiles {
+	for _, file := range pendingFiles {
+		// Commit every 100 files, counting those that were only sniffed
+		if processedCount > 0 && processedCount%100 == 0 {
+			tx.Commit()
+			tx, _ = DB.Begin()
+			updateStmt, _ = tx.Prepare(updateQuery)
+		}
 		processedCount++
 
 		percent := (processedCount * 100) / total
-		fmt.Printf("\r[DeepScan] [%d/%d] (%d%%) Reading: %-40s", processedCount, total, percent, truncateString(filepath.Base(path), 40))
+		fmt.Printf("\r[DeepScan] [%d/%d] (%d%%) Reading: %-40s", processedCount, total, percent, truncateString(filepath.Base(file.Path), 40))
+
+		// The extension already picks the extractor for readable files; only the rest are opened to sniff
+		mime := file.Mime
+		if file.Sniff {
+			mime = sql.NullString{String: SniffMime(file.Path), Valid: true}
+			file.Ext = extractorExt(file.Ext, mime.String)
+			if !isContentReadable(file.Ext) {
+				tx.Exec("UPDATE files SET mime = ? WHERE id = ?", mime, file.ID)
+				continue
+			}
+		}
+		content := getContentWithTimeout(file.Path, file.Ext)
+
+		// Mask secrets before anything reaches the index, metadata included;
+		// hits in events or contacts are recorded on the file they came from
+		var found map[string]int
+		content.Text, found = RedactSensitive(detectors, content.Text)
+		found = addFound(found, RedactMetadata(detectors, content.Metadata))
+		for i := range content.Entries {
+			var entryFound map[string]int
+			content.Entries[i].Text, entryFound = RedactSensitive(detectors, content.Entries[i].Text)
+			found = addFound(found, entryFound)
+			found = addFound(found, RedactMetadata(detectors, content.Entries[i].Metadata))
+		}
 
-		content := getContentWithTimeout(path)
+		var contentTime interface{}
+		if content.ContentTime > 0 {
+			contentTime = content.ContentTime
+		}
 
-		_, err := updateStmt.Exec(content, path)
-		if err != nil {
-			fmt.Printf("\nError saving %s: %v\n", path, err)
+		var lang interface{}
+		if code := DetectLanguage(content.Text); code != "" {
+			lang = code
 		}
 
-		if processedCount%100 == 0 {
-			tx.Commit()
-			tx, _ = DB.Begin()
-			updateStmt, _ = tx.Prepare("UPDATE files SET summary = ? WHERE path = ?")
+		_, err := updateStmt.Exec(content.Text, contentTime, lang, mime, file.ID)
+		if err == nil {
+			err = SaveMetadata(tx, file.ID, content.Metadata)
+		}
+		if err == nil {
+			err = SaveSegments(tx, file.ID, content.Segments)
+		}
+		if err == nil {
+			err = SaveLinks(tx, file.ID, file.Path, content.Links)
+		}
+		if err == nil {
+			err = SaveEntities(tx, file.ID, ExtractEntities(content.Text))
+		}
+		if err == nil {
+			err = SaveSensitiveFlags(tx, file.ID, found)
+		}
+		if err == nil {
+			err = SaveSubEntries(tx, file.ID, file.Path, file.ModTime, content.Entries)
+		}
+		if err != nil {
+			fmt.Printf("\nError saving %s: %v\n", file.Path, err)
 		}
 	}
 
 	tx.Commit()
+	markSummariesRedacted()
+	ResolvePendingLinks()
 	fmt.Printf("\nPHASE 2 Complete! Extracted text from %d files in %v\n", processedCount, time.Since(startTime))
 }
 
-func chunkText(text string, maxChunks int) []string {
-	words := strings.Fields(text)
-	var chunks []string
+// Chunk is one embedded window of a file's summary.
+type Chunk struct {
+	Index   int
+	Text    string
+	Start   int    // Byte offset in the summary
+	Locator string // Page etc. the chunk starts in ("" if unknown)
+}
 
+// chunkText splits text into overlapping word windows. Windows never cross a
+// segment boundary, so each chunk belongs to exactly one page.
+// Code segments are skipped when the file has prose: the model is trained on
+// natural language, so code vectors mostly add noise.
+func chunkText(text string, segments []Segment, maxChunks int) []Chunk {
 	chunkSize := 300
 	overlap := 50
 
-	for i := 0; i < len(words); i += (chunkSize - overlap) {
-		end := i + chunkSize
-		if end > len(words) {
-			end = len(words)
+	hasProse := false
+	for _, seg := range segments {
+		if !seg.Code {
+			hasProse = true
 		}
+	}
 
-		segment := strings.Join(words[i:end], " ")
-		chunks = append(chunks, segment)
+	// Text before the first segment (e.g. PDF title/author) is its own range
+	type span struct {
+		start, end int
+		locator    string
+		code       bool
+	}
+	var ranges []span
+	prev := span{start: 0}
+	for _, seg := range segments {
+		if seg.Start < prev.start || seg.Start > len(text) {
+			continue
+		}
+		prev.end = seg.Start
+		ranges = append(ranges, prev)
+		prev = span{start: seg.Start, locator: seg.Locator, code: seg.Code}
+	}
+	prev.end = len(text)
+	ranges = append(ranges, prev)
 
-		if len(chunks) >= maxChunks {
-			break
+	var chunks []Chunk
+	for _, r := range ranges {
+		if r.code && hasProse {
+			continue
+		}
+		words := wordOffsets(text[r.start:r.end])
+
+		for i := 0; i < len(words); i += (chunkSize - overlap) {
+			if len(chunks) >= maxChunks {
+				return chunks
+			}
+			end := i + chunkSize
+			if end > len(words) {
+				end = len(words)
+			}
+
+			chunks = append(chunks, Chunk{
+				Index:   len(chunks),
+				Text:    text[r.start+words[i][0] : r.start+words[end-1][1]],
+				Start:   r.start + words[i][0],
+				Locator: r.locator,
+			})
+			if end == len(words) {
+				break
+			}
 		}
 	}
 	if len(chunks) == 0 && len(text) > 0 {
-		return []string{text}
+		return []Chunk{{Text: text}}
 	}
 	return chunks
 }
 
+// wordOffsets returns the [start, end) byte range of every whitespace-separated word.
+func wordOffsets(text string) [][2]int {
+	var words [][2]int
+	start := -1
+	for i, r := range text {
+		if unicode.IsSpace(r) {
+			if start >= 0 {
+				words = append(words, [2]int{start, i})
+				start = -1
+			}
+		} else if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		words = append(words, [2]int{start, len(text)})
+	}
+	return words
+}
+
 func RunEmbeddingScan() {
 	if !IsAIReady {
 		fmt.Println("⚠️  AI Engine not ready. Skipping semantic indexing.")
@@ -398,31 +584,33 @@ func RunEmbeddingScan() {
 
 	count := 0
 
-	for id, summary := range pendingFiles {
+	for id, job := range pendingFiles {
+		summary := job.Summary
+		model := ModelForLanguage(job.Lang)
 		count++
 		percent := (count * 100) / total
 		fmt.Printf("\r[AI Scan] [%d/%d] (%d%%) Embedding...", count, total, percent)
 
-		var chunks []string
+		var chunks []Chunk
 
 		if CurrentSettings.EmbeddingStrategy == "simple" {
-			chunks = []string{summary}
+			chunks = []Chunk{{Text: summary}}
 		} else {
-			chunks = chunkText(summary, maxChunks)
+			chunks = chunkText(summary, LoadSegments(id), maxChunks)
 		}
 
-		for i, segment := range chunks {
-			if len(segment) < 10 {
+		for _, chunk := range chunks {
+			if len(chunk.Text) < 10 {
 				continue
 			}
 
-			vec, err := GetEmbedding(segment)
+			vec, err := EmbedWith(model, chunk.Text)
 			if err != nil {
 				fmt.Printf("\nAI Error on file %d: %v\n", id, err)
 				continue
 			}
 
-			SaveVector(id, i, vec)
+			SaveVector(id, chunk, vec, model.Name)
 		}
 	}
 
@@ -0,0 +1,139 @@
+package core
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Most frequent character trigrams per language ("_" = word boundary).
+// Enough to separate the Latin-script languages we see; Hindi is found by script.
+var languageTrigrams = map[string][]string{
+	"en": {
+		"_th", "the", "he_", "ing", "nd_", "_an", "and", "ed_", "_of", "of_",
+		"_to", "to_", "ion", "tio", "ent", "_in", "in_", "er_", "es_", "re_",
+		"on_", "at_", "_co", "is_", "_is", "her", "for", "_fo", "or_", "ati",
+		"hat", "tha", "ter", "_wh", "was", "_be", "ly_", "al_", "ve_", "it_",
+		"_re", "ers", "thi", "his", "you", "_yo", "are", "_ar", "_wi", "ith",
+	},
+	"de": {
+		"en_", "er_", "der", "ie_", "ich", "ein", "sch", "_de", "die", "_di",
+		"nd_", "und", "_un", "che", "den", "cht", "ine", "in_", "ten", "_ei",
+		"gen", "ung", "es_", "te_", "_da", "das", "ch_", "ist", "_is", "st_",
+		"nde", "ier", "ber", "_be", "_ve", "ver", "ge_", "auf", "_au", "ht_",
+		"eit", "mit", "_mi", "nic", "_ni", "sie", "zu_", "_zu", "dem", "ür_",
+	},
+	"fr": {
+		"es_", "_de", "de_", "le_", "_le", "ent", "nt_", "la_", "_la", "ion",
+		"tio", "les", "_pa", "que", "ue_", "_qu", "et_", "_et", "on_", "des",
+		"_co", "re_", "ne_", "est", "par", "our", "pou", "_po", "ait", "men",
+		"ans", "_da", "dan", "une", "_un", "_en", "en_", "ur_", "ons", "qui",
+		"_l'", "_d'", "eur", "ous", "_so", "_il", "il_", "ant", "tre", "_ce",
+	},
+	"es": {
+		"de_", "_de", "os_", "_la", "la_", "el_", "_el", "es_", "en_", "_en",
+		"ent", "que", "_qu", "ue_", "as_", "ión", "ció", "con", "_co", "los",
+		"_lo", "ado", "ara", "par", "_pa", "do_", "nte", "est", "del", "_se",
+		"er_", "ien", "una", "_un", "por", "_po", "cia", "las", "ra_", "ar_",
+		"ón_", "_es", "ero", "_qu", "sta", "_ta", "ta_", "_y_", "res", "ntr",
+	},
+}
+
+// Languages that get their own script check before trigrams
+var scriptLanguages = []struct {
+	Lang   string
+	Script *unicode.RangeTable
+}{
+	{"hi", unicode.Devanagari},
+}
+
+var trigramRanks map[string]map[string]int
+
+func init() {
+	trigramRanks = make(map[string]map[string]int, len(languageTrigrams))
+	for lang, grams := range languageTrigrams {
+		ranks := make(map[string]int, len(grams))
+		for i, g := range grams {
+			if _, seen := ranks[g]; !seen {
+				ranks[g] = len(grams) - i // Higher = more typical
+			}
+		}
+		trigramRanks[lang] = ranks
+	}
+}
+
+const langSampleSize = 8 * 1024 // Bytes of text inspected
+const langMinLetters = 40       // Shorter text is left undetected
+
+// DetectLanguage returns an ISO 639-1 code ("en", "de", "hi", ...) or "" when unsure.
+func DetectLanguage(text string) string {
+	if len(text) > langSampleSize {
+		text = truncateUTF8(text, langSampleSize)
+	}
+
+	// 1. Script: count letters per script (marks too: Devanagari vowel signs are marks)
+	letters := 0
+	scriptCounts := make([]int, len(scriptLanguages))
+	for _, r := range text {
+		if !unicode.IsLetter(r) && !unicode.IsMark(r) {
+			continue
+		}
+		letters++
+		for i, s := range scriptLanguages {
+			if unicode.Is(s.Script, r) {
+				scriptCounts[i]++
+			}
+		}
+	}
+	if letters < langMinLetters {
+		return ""
+	}
+	for i, s := range scriptLanguages {
+		if scriptCounts[i]*2 > letters {
+			return s.Lang
+		}
+	}
+
+	// 2. Latin: score trigram profiles
+	counts := make(map[string]int)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	}) {
+		padded := []rune("_" + word + "_")
+		for i := 0; i+3 <= len(padded); i++ {
+			counts[string(padded[i:i+3])]++
+		}
+	}
+
+	best, bestScore, secondScore := "", 0, 0
+	for lang, ranks := range trigramRanks {
+		score := 0
+		for gram, n := range counts {
+			score += ranks[gram] * n
+		}
+		if score > bestScore {
+			best, bestScore, secondScore = lang, score, bestScore
+		} else if score > secondScore {
+			secondScore = score
+		}
+	}
+
+	// Require a clear winner; mixed or code-heavy text stays unknown
+	if bestScore == 0 || float64(bestScore) < float64(secondScore)*1.2 {
+		return ""
+	}
+	return best
+}
+
+// langAliases lets users type lang:german as well as lang:de.
+var langAliases = map[string]string{
+	"english": "en", "german": "de", "deutsch": "de", "hindi": "hi",
+	"french": "fr", "francais": "fr", "spanish": "es", "espanol": "es",
+}
+
+func normalizeLang(value string) string {
+	value = strings.ToLower(value)
+	if code, ok := langAliases[value]; ok {
+		return code
+	}
+	return value
+}
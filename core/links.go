@@ -0,0 +1,250 @@
+package core
+
+import (
+	"database/sql"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Link is a reference from one note to another, as written in the source.
+type Link struct {
+	Target string
+	Wiki   bool // [[Target]] (resolved by name) vs [text](relative/path.md)
+}
+
+// [[Note]], [[Note|alias]], [[folder/Note#Heading]], ![[embed.png]]
+var wikiLinkRegex = regexp.MustCompile(`\[\[([^\]|#^]+)(?:[#^][^\]|]*)?(?:\|[^\]]*)?\]\]`)
+
+// [text](other.md), [text](../notes/other%20note.md "title")
+var mdLinkRegex = regexp.MustCompile(`\[[^\]]*\]\(<?([^)\s>]+)>?(?:\s+"[^"]*")?\)`)
+
+// extractLinks finds wiki and relative Markdown links in one line.
+func extractLinks(line string) []Link {
+	var links []Link
+	for _, m := range wikiLinkRegex.FindAllStringSubmatch(line, -1) {
+		if target := strings.TrimSpace(m[1]); target != "" {
+			links = append(links, Link{Target: target, Wiki: true})
+		}
+	}
+	for _, m := range mdLinkRegex.FindAllStringSubmatch(line, -1) {
+		target := m[1]
+		if strings.Contains(target, "://") || strings.HasPrefix(target, "mailto:") || strings.HasPrefix(target, "#") {
+			continue
+		}
+		if i := strings.IndexByte(target, '#'); i >= 0 {
+			target = target[:i]
+		}
+		if unescaped, err := url.PathUnescape(target); err == nil {
+			target = unescaped
+		}
+		links = append(links, Link{Target: target})
+	}
+	return links
+}
+
+// linkResolver is satisfied by both *sql.DB and *sql.Tx.
+type linkResolver interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// resolveLink maps a link to a file id, or 0 when the target is not indexed.
+func resolveLink(db linkResolver, sourcePath string, link Link) int {
+	target := filepath.FromSlash(link.Target)
+
+	if !link.Wiki {
+		abs := filepath.Clean(filepath.Join(filepath.Dir(sourcePath), target))
+		candidates := []string{abs}
+		if noteExt(abs) == "" {
+			candidates = append(candidates, abs+".md")
+		}
+		for _, p := range candidates {
+			var id int
+			if err := db.QueryRow("SELECT id FROM files WHERE path = ? COLLATE NOCASE", p).Scan(&id); err == nil {
+				return id
+			}
+		}
+		return 0
+	}
+
+	// Wiki links name a note, optionally with part of its folder path. Names
+	// may contain dots ("2024.01.05 Meeting"), so only a note extension counts;
+	// anything else is tried as a note first and as an attachment name second
+	name := filepath.Base(target)
+	stem := strings.TrimSuffix(target, noteExt(target))
+	names := []interface{}{name, name}
+	if noteExt(name) == "" {
+		names[0] = name + ".md"
+	}
+	suffix := strings.ToLower(string(filepath.Separator) + stem)
+
+	rows, err := db.Query("SELECT id, path FROM files WHERE filename COLLATE NOCASE IN (?, ?)", names...)
+	if err != nil {
+		return 0
+	}
+	defer rows.Close()
+
+	// Like Obsidian: prefer the note in the same folder, then the shortest path
+	sourceDir := strings.ToLower(filepath.Dir(sourcePath))
+	bestID, bestPath := 0, ""
+	for rows.Next() {
+		var id int
+		var path string
+		if rows.Scan(&id, &path) != nil {
+			continue
+		}
+		lower := strings.ToLower(path)
+		if !strings.HasSuffix(strings.TrimSuffix(lower, noteExt(lower)), suffix) {
+			continue
+		}
+		if filepath.Dir(lower) == sourceDir {
+			return id
+		}
+		if bestID == 0 || len(path) < len(bestPath) {
+			bestID, bestPath = id, path
+		}
+	}
+	return bestID
+}
+
+// noteExt returns the Markdown extension path ends in, or "" for any other name.
+func noteExt(path string) string {
+	switch ext := filepath.Ext(path); strings.ToLower(ext) {
+	case ".md", ".markdown":
+		return ext
+	}
+	return ""
+}
+
+// SaveLinks replaces a note's outgoing links. Unresolved links keep target_id NULL
+// and are retried by ResolvePendingLinks once more files are indexed.
+func SaveLinks(tx *sql.Tx, fileID int, sourcePath string, links []Link) error {
+	invalidateBacklinks()
+	if _, err := tx.Exec("DELETE FROM file_links WHERE source_id = ?", fileID); err != nil {
+		return err
+	}
+	for _, link := range links {
+		var targetID interface{}
+		if id := resolveLink(tx, sourcePath, link); id > 0 && id != fileID {
+			targetID = id
+		}
+		if _, err := tx.Exec("INSERT INTO file_links (source_id, target_id, target, wiki) VALUES (?, ?, ?, ?)",
+			fileID, targetID, link.Target, link.Wiki); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ResolvePendingLinks retries links whose target did not exist when the source was scanned.
+func ResolvePendingLinks() {
+	rows, err := DB.Query(`
+		SELECT l.rowid, f.id, f.path, l.target, l.wiki FROM file_links l
+		JOIN files f ON f.id = l.source_id
+		WHERE l.target_id IS NULL`)
+	if err != nil {
+		return
+	}
+
+	type pending struct {
+		RowID, SourceID int
+		SourcePath      string
+		Link            Link
+	}
+	var todo []pending
+	for rows.Next() {
+		var p pending
+		if rows.Scan(&p.RowID, &p.SourceID, &p.SourcePath, &p.Link.Target, &p.Link.Wiki) == nil {
+			todo = append(todo, p)
+		}
+	}
+	rows.Close()
+
+	for _, p := range todo {
+		if id := resolveLink(DB, p.SourcePath, p.Link); id > 0 && id != p.SourceID {
+			DB.Exec("UPDATE file_links SET target_id = ? WHERE rowid = ?", id, p.RowID)
+		}
+	}
+	// Also picks up links saved in transactions committed since the last read
+	invalidateBacklinks()
+}
+
+// GetOutgoingLinks returns the indexed files a note links to.
+func GetOutgoingLinks(path string) []string {
+	return queryPaths(`
+		SELECT DISTINCT t.path FROM file_links l
+		JOIN files s ON s.id = l.source_id
+		JOIN files t ON t.id = l.target_id
+		WHERE s.path = ? ORDER BY t.path`, path)
+}
+
+// GetBacklinks returns the indexed files that link to this one.
+func GetBacklinks(path string) []string {
+	return queryPaths(`
+		SELECT DISTINCT s.path FROM file_links l
+		JOIN files s ON s.id = l.source_id
+		JOIN files t ON t.id = l.target_id
+		WHERE t.path = ? ORDER BY s.path`, path)
+}
+
+func queryPaths(query string, args ...interface{}) []string {
+	paths := []string{}
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return paths
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p string
+		if rows.Scan(&p) == nil {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// Backlink counts are kept between searches until links are written again
+var (
+	backlinkMutex  sync.Mutex
+	backlinkCounts map[string]float32 // nil = not loaded
+)
+
+func invalidateBacklinks() {
+	backlinkMutex.Lock()
+	backlinkCounts = nil
+	backlinkMutex.Unlock()
+}
+
+// GetBacklinkCounts maps each linked-to path to the number of notes linking
+// to it. The map is shared; callers must not modify it.
+func GetBacklinkCounts() map[string]float32 {
+	backlinkMutex.Lock()
+	defer backlinkMutex.Unlock()
+	if backlinkCounts != nil {
+		return backlinkCounts
+	}
+
+	counts := make(map[string]float32)
+	rows, err := DB.Query(`
+		SELECT t.path, COUNT(DISTINCT l.source_id) FROM file_links l
+		JOIN files t ON t.id = l.target_id
+		GROUP BY l.target_id`)
+	if err != nil {
+		return counts
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var path string
+		var count int
+		if rows.Scan(&path, &count) == nil {
+			counts[path] = float32(count)
+		}
+	}
+	backlinkCounts = counts
+	return counts
+}
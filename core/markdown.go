@@ -0,0 +1,205 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const MaxMarkdownSize = 1024 * 1024
+
+var headingRegex = regexp.MustCompile(`^ {0,3}(#{1,6})\s+(.+?)\s*#*\s*$`)
+
+// #tag, #nested/tag. Must start with a letter so "#1" and "C#" are not tags.
+var hashtagRegex = regexp.MustCompile(`(?:^|\s)#(\p{L}[\p{L}\p{N}_/-]*)`)
+
+var inlineCodeRegex = regexp.MustCompile("`[^`]*`")
+
+// readMarkdownContent parses YAML front matter, splits the body at headings
+// (one segment per section) and collects #hashtags and links to other notes.
+func readMarkdownContent(path string) Extraction {
+	res := newExtraction("")
+
+	f, err := os.Open(path)
+	if err != nil {
+		return res
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, MaxMarkdownSize))
+	if err != nil {
+		return res
+	}
+
+	body := strings.ReplaceAll(strings.TrimPrefix(string(data), "\ufeff"), "\r\n", "\n")
+	front, body := splitFrontMatter(body)
+
+	var sb strings.Builder
+	if front != nil {
+		sb.WriteString(applyFrontMatter(front, &res))
+	}
+
+	var section []string
+	flush := func() {
+		if text := cleanText(strings.Join(section, "\n"), false); text != "" {
+			if sb.Len() > 0 {
+				sb.WriteByte(' ')
+			}
+			sb.WriteString(text)
+		}
+		section = nil
+	}
+
+	inFence := false
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			section = append(section, line)
+			continue
+		}
+		if inFence {
+			section = append(section, line)
+			continue
+		}
+
+		if m := headingRegex.FindStringSubmatch(line); m != nil {
+			flush()
+			start := sb.Len()
+			if start > 0 {
+				start++ // flush() writes a separator before the section
+			}
+			res.Segments = append(res.Segments, Segment{Start: start, Locator: truncateUTF8(m[2], 60)})
+			section = append(section, m[2])
+			continue
+		}
+
+		prose := inlineCodeRegex.ReplaceAllString(line, "")
+		for _, m := range hashtagRegex.FindAllStringSubmatch(prose, -1) {
+			res.Metadata.Add("tag", strings.TrimRight(m[1], "/-"))
+		}
+		res.Links = append(res.Links, extractLinks(prose)...)
+		section = append(section, line)
+	}
+	flush()
+
+	res.Text = sb.String()
+	return res
+}
+
+// splitFrontMatter separates a leading "---" YAML block from the body.
+func splitFrontMatter(text string) (map[string]interface{}, string) {
+	if !strings.HasPrefix(text, "---\n") {
+		return nil, text
+	}
+	rest := text[4:]
+
+	end := -1
+	for _, marker := range []string{"\n---\n", "\n...\n"} {
+		if i := strings.Index(rest, marker); i >= 0 && (end < 0 || i < end) {
+			end = i
+		}
+	}
+	closing := 5
+	if end < 0 {
+		// Front matter closed at EOF
+		if strings.HasSuffix(rest, "\n---") {
+			end, closing = len(rest)-4, 4
+		} else {
+			return nil, text
+		}
+	}
+
+	var front map[string]interface{}
+	if err := yaml.Unmarshal([]byte(rest[:end]), &front); err != nil {
+		return nil, text
+	}
+	return front, rest[end+closing:]
+}
+
+// applyFrontMatter stores title/tags/date as metadata and returns the other
+// scalar fields as searchable text.
+func applyFrontMatter(front map[string]interface{}, res *Extraction) string {
+	var desc []string
+
+	keys := make([]string, 0, len(front))
+	for key := range front {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := front[key]
+		switch strings.ToLower(key) {
+		case "title":
+			title := fmt.Sprint(value)
+			res.Metadata.Add("title", title)
+			desc = append(desc, title)
+		case "tags", "tag", "keywords":
+			for _, tag := range frontMatterList(value) {
+				tag = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+				res.Metadata.Add("tag", tag)
+				desc = append(desc, tag)
+			}
+		case "aliases", "alias":
+			for _, alias := range frontMatterList(value) {
+				res.Metadata.Add("alias", alias)
+				desc = append(desc, alias)
+			}
+		case "date", "created", "created_at":
+			if t := frontMatterDate(value); !t.IsZero() {
+				res.Metadata.Add("date", t.Format("2006-01-02"))
+				if res.ContentTime == 0 || strings.ToLower(key) == "date" {
+					res.ContentTime = t.Unix()
+				}
+			}
+		default:
+			if s, ok := value.(string); ok {
+				desc = append(desc, s)
+			}
+		}
+	}
+
+	return cleanText(strings.Join(desc, " "), false)
+}
+
+// frontMatterList accepts both "tags: [a, b]" and "tags: a, b".
+func frontMatterList(value interface{}) []string {
+	switch v := value.(type) {
+	case []interface{}:
+		var out []string
+		for _, item := range v {
+			if item != nil {
+				out = append(out, fmt.Sprint(item))
+			}
+		}
+		return out
+	case string:
+		return strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' })
+	}
+	return nil
+}
+
+func frontMatterDate(value interface{}) time.Time {
+	switch v := value.(type) {
+	case time.Time:
+		// YAML reads a bare "2024-03-05" as UTC midnight; notes mean the local day
+		if v.Location() == time.UTC && v.Hour() == 0 && v.Minute() == 0 && v.Second() == 0 {
+			return time.Date(v.Year(), v.Month(), v.Day(), 0, 0, 0, 0, time.Local)
+		}
+		return v
+	case string:
+		for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04", "2006-01-02 15:04", "2006-01-02", "2006/01/02", "02.01.2006"} {
+			if t, err := time.ParseInLocation(layout, strings.TrimSpace(v), time.Local); err == nil {
+				return t
+			}
+		}
+	}
+	return time.Time{}
+}
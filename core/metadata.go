@@ -0,0 +1,251 @@
+package core
+
+import (
+	"database/sql"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Metadata holds the structured fields an extractor pulls out of a file.
+// A key can carry several values (e.g. multiple tags).
+type Metadata map[string][]string
+
+func (m Metadata) Add(key, value string) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return
+	}
+	for _, v := range m[key] {
+		if v == value {
+			return
+		}
+	}
+	m[key] = append(m[key], value)
+}
+
+func (m Metadata) Get(key string) string {
+	if vals := m[key]; len(vals) > 0 {
+		return vals[0]
+	}
+	return ""
+}
+
+// Extraction is what a content extractor hands back to the Deep Scan.
+type Extraction struct {
+	Text     string
+	Metadata Metadata
+	// The document's own date (EXIF date taken, etc.). 0 = unknown, use mtime.
+	ContentTime int64
+	// Where each part (page, ...) starts in Text. Empty for unstructured files.
+	Segments []Segment
+	// References to other files (Markdown notes)
+	Links []Link
+	// Items inside the file indexed as their own results (calendar events, contacts)
+	Entries []SubEntry
+}
+
+// SubEntry is a virtual file stored as "<parent path>::<Key>".
+type SubEntry struct {
+	Key         string // e.g. "event/<UID>", or "event/3" without a UID
+	Name        string // Shown instead of the filename
+	Text        string
+	Metadata    Metadata
+	ContentTime int64
+}
+
+// VirtualSeparator joins a parent path and a sub-entry key.
+const VirtualSeparator = "::"
+
+func (e *SubEntry) addText(text string) {
+	if text = strings.TrimSpace(text); text != "" {
+		e.Text += text + " "
+	}
+}
+
+func (e *SubEntry) finish() {
+	e.Text = cleanText(e.Text, false)
+	if e.Name == "" {
+		e.Name = e.Key
+	}
+}
+
+// ParentPath strips the sub-entry key from a virtual path.
+func ParentPath(path string) string {
+	if i := strings.Index(path, VirtualSeparator); i >= 0 {
+		return path[:i]
+	}
+	return path
+}
+
+// Segment marks a byte offset in the summary where a locatable part begins.
+type Segment struct {
+	Start   int
+	Locator string // Shown to the user, e.g. "p. 12"
+	Code    bool   // Source code rather than prose (notebook code cells)
+}
+
+func newExtraction(text string) Extraction {
+	return Extraction{Text: text, Metadata: Metadata{}}
+}
+
+// appendText adds searchable text (e.g. a metadata description) to the summary.
+func (e *Extraction) appendText(text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	if e.Text == "" {
+		e.Text = text
+		return
+	}
+	e.Text += " " + text
+}
+
+// --- DB HELPERS ---
+
+// SaveMetadata replaces all stored metadata for a file.
+func SaveMetadata(tx *sql.Tx, fileID int, meta Metadata) error {
+	if _, err := tx.Exec("DELETE FROM file_metadata WHERE file_id = ?", fileID); err != nil {
+		return err
+	}
+	if len(meta) == 0 {
+		return nil
+	}
+
+	stmt, err := tx.Prepare("INSERT INTO file_metadata (file_id, key, value) VALUES (?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for key, values := range meta {
+		for _, v := range values {
+			if _, err := stmt.Exec(fileID, key, v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SaveSegments replaces the stored page/section boundaries for a file.
+func SaveSegments(tx *sql.Tx, fileID int, segments []Segment) error {
+	if _, err := tx.Exec("DELETE FROM file_segments WHERE file_id = ?", fileID); err != nil {
+		return err
+	}
+	if len(segments) == 0 {
+		return nil
+	}
+
+	stmt, err := tx.Prepare("INSERT INTO file_segments (file_id, start_offset, locator, is_code) VALUES (?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, seg := range segments {
+		if _, err := stmt.Exec(fileID, seg.Start, seg.Locator, seg.Code); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadSegments returns a file's boundaries ordered by offset.
+func LoadSegments(fileID int) []Segment {
+	rows, err := DB.Query("SELECT start_offset, locator, COALESCE(is_code, 0) FROM file_segments WHERE file_id = ? ORDER BY start_offset", fileID)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	var segments []Segment
+	for rows.Next() {
+		var seg Segment
+		if err := rows.Scan(&seg.Start, &seg.Locator, &seg.Code); err == nil {
+			segments = append(segments, seg)
+		}
+	}
+	return segments
+}
+
+// EntryName returns the stored display name of a virtual entry.
+func EntryName(path string) string {
+	var name string
+	DB.QueryRow("SELECT filename FROM files WHERE path = ?", path).Scan(&name)
+	return name
+}
+
+// SaveSubEntries replaces the virtual rows of a file. They inherit its
+// extension and mtime and carry parent_id so they are removed with it.
+func SaveSubEntries(tx *sql.Tx, parentID int, parentPath string, modTime int64, entries []SubEntry) error {
+	cleanup := []string{
+		"DELETE FROM file_vectors WHERE file_id IN (SELECT id FROM files WHERE parent_id = ?)",
+		"DELETE FROM file_metadata WHERE file_id IN (SELECT id FROM files WHERE parent_id = ?)",
+		"DELETE FROM file_entities WHERE file_id IN (SELECT id FROM files WHERE parent_id = ?)",
+		"DELETE FROM files WHERE parent_id = ?",
+	}
+	for _, q := range cleanup {
+		if _, err := tx.Exec(q, parentID); err != nil {
+			return err
+		}
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	stmt, err := tx.Prepare(`INSERT INTO files (path, filename, extension, modified_time, summary, content_time, parent_id, lang, initials) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	ext := filepath.Ext(parentPath)
+	for _, e := range entries {
+		var contentTime interface{}
+		if e.ContentTime > 0 {
+			contentTime = e.ContentTime
+		}
+		var lang interface{}
+		if code := DetectLanguage(e.Text); code != "" {
+			lang = code
+		}
+		r, err := stmt.Exec(parentPath+VirtualSeparator+e.Key, e.Name, ext, modTime, e.Text, contentTime, parentID, lang, nameInitials(e.Name))
+		if err != nil {
+			return err
+		}
+		id, _ := r.LastInsertId()
+		if err := SaveMetadata(tx, int(id), e.Metadata); err != nil {
+			return err
+		}
+		if err := SaveEntities(tx, int(id), ExtractEntities(e.Text)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetFileMetadata returns the stored metadata for a path (empty if none).
+func GetFileMetadata(path string) Metadata {
+	meta := Metadata{}
+	rows, err := DB.Query(`
+		SELECT m.key, m.value FROM file_metadata m
+		JOIN files f ON f.id = m.file_id
+		WHERE f.path = ?`, path)
+	if err != nil {
+		return meta
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err == nil {
+			meta[key] = append(meta[key], value)
+		}
+	}
+	for _, vals := range meta {
+		sort.Strings(vals)
+	}
+	return meta
+}
@@ -0,0 +1,239 @@
+package core
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const sniffSize = 512 // Bytes http.DetectContentType looks at
+
+// Magic numbers http.DetectContentType does not know (or reports too coarsely).
+var magicSignatures = []struct {
+	Offset int
+	Magic  string
+	Mime   string
+}{
+	{0, "II*\x00", "image/tiff"},
+	{0, "MM\x00*", "image/tiff"},
+	{0, "fLaC", "audio/flac"},
+	{0, "ID3", "audio/mpeg"},
+	{0, "\xd0\xcf\x11\xe0\xa1\xb1\x1a\xe1", "application/x-ole-storage"}, // Legacy .doc/.xls/.ppt, .msg
+	{0, "7z\xbc\xaf\x27\x1c", "application/x-7z-compressed"},
+	{0, "SQLite format 3\x00", "application/vnd.sqlite3"},
+	{0, "{\\rtf", "application/rtf"},
+	{0, "MZ", "application/vnd.microsoft.portable-executable"},
+}
+
+// Zip-based office/ebook formats, recognized by their first entry or a marker path
+var zipMarkers = []struct {
+	Prefix string
+	Mime   string
+}{
+	{"word/", "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+	{"xl/", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+	{"ppt/", "application/vnd.openxmlformats-officedocument.presentationml.presentation"},
+}
+
+// SniffMime identifies a file from its leading bytes. Returns "" if unreadable.
+func SniffMime(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	head := make([]byte, sniffSize)
+	n, err := io.ReadFull(f, head)
+	if n == 0 {
+		return ""
+	}
+	head = head[:n]
+
+	for _, sig := range magicSignatures {
+		if bytes.HasPrefix(head[sig.Offset:], []byte(sig.Magic)) {
+			return sig.Mime
+		}
+	}
+
+	switch {
+	case n >= 12 && string(head[4:8]) == "ftyp":
+		// ISO base media: the major brand tells audio, QuickTime and MP4 apart
+		switch string(head[8:12]) {
+		case "M4A ", "M4B ":
+			return "audio/mp4"
+		case "qt  ":
+			return "video/quicktime"
+		case "heic", "heix", "mif1":
+			return "image/heic"
+		}
+		return "video/mp4"
+	case bytes.HasPrefix(head, []byte("\x1a\x45\xdf\xa3")):
+		if bytes.Contains(head, []byte("webm")) {
+			return "video/webm"
+		}
+		return "video/x-matroska"
+	case bytes.HasPrefix(head, []byte("OggS")):
+		if bytes.Contains(head, []byte("OpusHead")) {
+			return "audio/opus"
+		}
+		if bytes.Contains(head, []byte("\x01vorbis")) {
+			return "audio/ogg"
+		}
+		return "application/ogg"
+	case n >= 2 && head[0] == 0xff && head[1]&0xe0 == 0xe0 && head[1]&0x06 != 0:
+		return "audio/mpeg" // MPEG audio frame sync without an ID3 tag
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")):
+		return sniffZip(path)
+	}
+
+	mime := http.DetectContentType(head)
+	if i := strings.IndexByte(mime, ';'); i >= 0 {
+		mime = mime[:i]
+	}
+	return mime
+}
+
+// sniffZip looks inside a zip for OOXML folders or an ODF/EPUB "mimetype" entry.
+func sniffZip(path string) string {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return "application/zip"
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		if f.Name == "mimetype" && f.UncompressedSize64 < 256 {
+			if rc, err := f.Open(); err == nil {
+				data, _ := io.ReadAll(rc)
+				rc.Close()
+				if mime := strings.TrimSpace(string(data)); mime != "" {
+					return mime
+				}
+			}
+		}
+		for _, m := range zipMarkers {
+			if strings.HasPrefix(f.Name, m.Prefix) {
+				return m.Mime
+			}
+		}
+	}
+	return "application/zip"
+}
+
+// mimeExtensions maps a sniffed type to the extension whose extractor reads it.
+var mimeExtensions = map[string]string{
+	"application/pdf": ".pdf",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": ".docx",
+	"application/rtf":  ".rtf",
+	"image/jpeg":       ".jpg",
+	"image/png":        ".png",
+	"image/webp":       ".webp",
+	"image/tiff":       ".tiff",
+	"audio/mpeg":       ".mp3",
+	"audio/flac":       ".flac",
+	"audio/ogg":        ".ogg",
+	"audio/opus":       ".opus",
+	"audio/mp4":        ".m4a",
+	"video/mp4":        ".mp4",
+	"video/quicktime":  ".mov",
+	"video/x-matroska": ".mkv",
+	"video/webm":       ".webm",
+}
+
+// Extensions that are never worth opening to sniff (binaries, system files)
+var noSniffExtensions = map[string]bool{
+	".exe": true, ".dll": true, ".sys": true, ".msi": true, ".lib": true, ".obj": true,
+	".o": true, ".a": true, ".so": true, ".pdb": true, ".class": true, ".pyc": true,
+	".cab": true, ".mui": true, ".cat": true, ".lnk": true, ".tmp": true, ".log": true,
+}
+
+// extractorExt picks the extension used to choose an extractor: the real one
+// when we can read it, otherwise the one implied by the sniffed type. Text is
+// only assumed for files without any extension, so unknown source/data formats
+// are not pulled in wholesale.
+func extractorExt(ext, mime string) string {
+	ext = strings.ToLower(ext)
+	if isContentReadable(ext) {
+		return ext
+	}
+	if mapped, ok := mimeExtensions[mime]; ok {
+		return mapped
+	}
+	if ext == "" && mime == "text/plain" {
+		return ".txt"
+	}
+	return ext
+}
+
+// fileKinds groups types for the kind: filter. Matching is by sniffed type or
+// by extension, since most files are never opened.
+var fileKinds = map[string]struct {
+	MimePrefixes []string
+	Extensions   []string
+}{
+	"image": {
+		[]string{"image/"},
+		[]string{".jpg", ".jpeg", ".png", ".gif", ".bmp", ".webp", ".tif", ".tiff", ".ico", ".heic", ".svg"},
+	},
+	"audio": {
+		[]string{"audio/"},
+		[]string{".mp3", ".flac", ".ogg", ".oga", ".opus", ".m4a", ".wav", ".aac", ".wma"},
+	},
+	"video": {
+		[]string{"video/"},
+		[]string{".mp4", ".m4v", ".mov", ".mkv", ".webm", ".avi", ".wmv", ".flv", ".mpg", ".mpeg"},
+	},
+	"document": {
+		[]string{"application/pdf", "application/rtf", "application/msword", "application/x-ole-storage",
+			"application/vnd.openxmlformats-officedocument.", "application/vnd.oasis.opendocument.", "application/epub+zip"},
+		[]string{".pdf", ".doc", ".docx", ".rtf", ".odt", ".xls", ".xlsx", ".ods", ".ppt", ".pptx", ".odp", ".epub"},
+	},
+	"text": {
+		[]string{"text/"},
+		[]string{".txt", ".md", ".markdown", ".csv", ".tsv", ".json", ".yaml", ".yml", ".toml", ".srt", ".vtt", ".ics", ".vcf", ".ipynb", ".log"},
+	},
+	"archive": {
+		[]string{"application/zip", "application/x-7z-compressed", "application/x-gzip", "application/gzip",
+			"application/x-rar-compressed", "application/vnd.rar", "application/x-tar"},
+		[]string{".zip", ".7z", ".gz", ".tgz", ".rar", ".tar"},
+	},
+}
+
+var kindAliases = map[string]string{
+	"images": "image", "photo": "image", "photos": "image", "picture": "image", "pictures": "image",
+	"music": "audio", "song": "audio", "songs": "audio",
+	"videos": "video", "movie": "video", "movies": "video",
+	"doc": "document", "docs": "document", "documents": "document",
+	"archives": "archive",
+}
+
+// kindClause renders kind:value as a condition on alias "f". Unknown kinds are
+// read as a mime prefix or an extension, so kind:pdf and kind:application/zip work too.
+func kindClause(kind string) (string, []interface{}) {
+	kind = strings.ToLower(kind)
+	if alias, ok := kindAliases[kind]; ok {
+		kind = alias
+	}
+
+	k, ok := fileKinds[kind]
+	if !ok {
+		return `(COALESCE(f.mime, '') LIKE ? ESCAPE '\' OR lower(f.extension) = ?)`, []interface{}{likeEscape(kind) + "%", "." + strings.TrimPrefix(kind, ".")}
+	}
+
+	var conds []string
+	var args []interface{}
+	for _, prefix := range k.MimePrefixes {
+		conds = append(conds, "COALESCE(f.mime, '') LIKE ?")
+		args = append(args, prefix+"%")
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(k.Extensions)), ",")
+	conds = append(conds, "lower(f.extension) IN ("+placeholders+")")
+	for _, ext := range k.Extensions {
+		args = append(args, ext)
+	}
+	return "(" + strings.Join(conds, " OR ") + ")", args
+}
@@ -0,0 +1,260 @@
+package core
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+)
+
+// MKVInfo is what we read from a Matroska / WebM EBML header.
+type MKVInfo struct {
+	DocType      string // "matroska" or "webm"
+	Title        string
+	Duration     float64 // Seconds
+	CreationTime time.Time
+
+	Width      int
+	Height     int
+	VideoCodec string // CodecID of the first video track (V_MPEG4/ISO/AVC, ...)
+	AudioCodec string
+}
+
+// EBML element IDs (marker bits included)
+const (
+	ebmlHeader       = 0x1A45DFA3
+	ebmlDocType      = 0x4282
+	mkvSegment       = 0x18538067
+	mkvInfo          = 0x1549A966
+	mkvTimecodeScale = 0x2AD7B1
+	mkvDuration      = 0x4489
+	mkvDateUTC       = 0x4461
+	mkvTitle         = 0x7BA9
+	mkvTracks        = 0x1654AE6B
+	mkvTrackEntry    = 0xAE
+	mkvTrackType     = 0x83
+	mkvCodecID       = 0x86
+	mkvVideo         = 0xE0
+	mkvPixelWidth    = 0xB0
+	mkvPixelHeight   = 0xBA
+	mkvCluster       = 0x1F43B675
+)
+
+// Matroska dates count nanoseconds from 2001-01-01 UTC
+var mkvEpoch = time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+
+type ebmlElement struct {
+	ID    uint64
+	Start int64 // Payload
+	End   int64
+}
+
+// ReadMKV reads the Info and Tracks elements. Parsing stops at the first
+// Cluster, which is where the media data begins.
+func ReadMKV(path string) (*MKVInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := stat.Size()
+
+	header, err := readEBMLElement(f, 0, size)
+	if err != nil || header.ID != ebmlHeader {
+		return nil, fmt.Errorf("not an ebml file")
+	}
+
+	info := &MKVInfo{}
+	for _, el := range readEBMLChildren(f, header) {
+		if el.ID == ebmlDocType {
+			info.DocType = string(readEBMLBytes(f, el, 64))
+		}
+	}
+	if info.DocType != "matroska" && info.DocType != "webm" {
+		return nil, fmt.Errorf("unsupported doctype %q", info.DocType)
+	}
+
+	segment, err := readEBMLElement(f, header.End, size)
+	if err != nil || segment.ID != mkvSegment {
+		return nil, fmt.Errorf("no segment")
+	}
+
+	timecodeScale := 1000000.0 // Default: 1ms
+	var rawDuration float64
+
+	for _, el := range readEBMLChildren(f, segment) {
+		switch el.ID {
+		case mkvInfo:
+			for _, child := range readEBMLChildren(f, el) {
+				switch child.ID {
+				case mkvTimecodeScale:
+					if v := readEBMLUint(f, child); v > 0 {
+						timecodeScale = float64(v)
+					}
+				case mkvDuration:
+					rawDuration = readEBMLFloat(f, child)
+				case mkvDateUTC:
+					if ns := int64(readEBMLUint(f, child)); ns != 0 {
+						info.CreationTime = mkvEpoch.Add(time.Duration(ns))
+					}
+				case mkvTitle:
+					info.Title = string(readEBMLBytes(f, child, 4096))
+				}
+			}
+		case mkvTracks:
+			for _, entry := range readEBMLChildren(f, el) {
+				if entry.ID == mkvTrackEntry {
+					parseMKVTrack(f, entry, info)
+				}
+			}
+		}
+	}
+
+	info.Duration = rawDuration * timecodeScale / 1e9
+	return info, nil
+}
+
+func parseMKVTrack(r io.ReaderAt, entry ebmlElement, info *MKVInfo) {
+	var trackType uint64
+	var codec string
+	var width, height int
+
+	for _, el := range readEBMLChildren(r, entry) {
+		switch el.ID {
+		case mkvTrackType:
+			trackType = readEBMLUint(r, el)
+		case mkvCodecID:
+			codec = string(readEBMLBytes(r, el, 64))
+		case mkvVideo:
+			for _, v := range readEBMLChildren(r, el) {
+				switch v.ID {
+				case mkvPixelWidth:
+					width = int(readEBMLUint(r, v))
+				case mkvPixelHeight:
+					height = int(readEBMLUint(r, v))
+				}
+			}
+		}
+	}
+
+	switch trackType {
+	case 1: // Video
+		if info.VideoCodec == "" {
+			info.VideoCodec = codec
+			info.Width = width
+			info.Height = height
+		}
+	case 2: // Audio
+		if info.AudioCodec == "" {
+			info.AudioCodec = codec
+		}
+	}
+}
+
+// readVint reads an EBML variable-length integer. For IDs the marker bit is kept.
+func readVint(r io.ReaderAt, pos int64, keepMarker bool) (uint64, int, error) {
+	first := make([]byte, 1)
+	if _, err := r.ReadAt(first, pos); err != nil {
+		return 0, 0, err
+	}
+	length := 1
+	for mask := byte(0x80); length <= 8 && first[0]&mask == 0; mask >>= 1 {
+		length++
+	}
+	if length > 8 {
+		return 0, 0, fmt.Errorf("invalid vint")
+	}
+
+	buf := make([]byte, length)
+	if _, err := r.ReadAt(buf, pos); err != nil {
+		return 0, 0, err
+	}
+	if !keepMarker {
+		buf[0] &= byte(0xFF >> length)
+	}
+	var v uint64
+	for _, b := range buf {
+		v = v<<8 | uint64(b)
+	}
+	return v, length, nil
+}
+
+func readEBMLElement(r io.ReaderAt, pos, limit int64) (ebmlElement, error) {
+	id, idLen, err := readVint(r, pos, true)
+	if err != nil {
+		return ebmlElement{}, err
+	}
+	size, sizeLen, err := readVint(r, pos+int64(idLen), false)
+	if err != nil {
+		return ebmlElement{}, err
+	}
+
+	start := pos + int64(idLen+sizeLen)
+	end := start + int64(size)
+	// All-ones size = "unknown" (live streams); treat as running to the parent's end
+	if size == (uint64(1)<<(7*uint(sizeLen)))-1 || end > limit || end < start {
+		end = limit
+	}
+	return ebmlElement{ID: id, Start: start, End: end}, nil
+}
+
+func readEBMLChildren(r io.ReaderAt, parent ebmlElement) []ebmlElement {
+	var children []ebmlElement
+	pos := parent.Start
+	for pos < parent.End && len(children) < 1000 {
+		el, err := readEBMLElement(r, pos, parent.End)
+		if err != nil || el.ID == mkvCluster {
+			break
+		}
+		children = append(children, el)
+		pos = el.End
+	}
+	return children
+}
+
+func readEBMLRaw(r io.ReaderAt, el ebmlElement, limit int64) []byte {
+	n := el.End - el.Start
+	if n <= 0 || n > limit {
+		return nil
+	}
+	buf := make([]byte, n)
+	if _, err := r.ReadAt(buf, el.Start); err != nil {
+		return nil
+	}
+	return buf
+}
+
+// readEBMLBytes reads a string element; strings may be NUL padded.
+func readEBMLBytes(r io.ReaderAt, el ebmlElement, limit int64) []byte {
+	buf := readEBMLRaw(r, el, limit)
+	for len(buf) > 0 && buf[len(buf)-1] == 0 {
+		buf = buf[:len(buf)-1]
+	}
+	return buf
+}
+
+func readEBMLUint(r io.ReaderAt, el ebmlElement) uint64 {
+	var v uint64
+	for _, b := range readEBMLRaw(r, el, 8) {
+		v = v<<8 | uint64(b)
+	}
+	return v
+}
+
+func readEBMLFloat(r io.ReaderAt, el ebmlElement) float64 {
+	raw := readEBMLRaw(r, el, 8)
+	if len(raw) != 4 && len(raw) != 8 {
+		return 0
+	}
+	if len(raw) == 4 {
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(raw)))
+	}
+	return math.Float64frombits(binary.BigEndian.Uint64(raw))
+}
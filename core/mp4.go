@@ -0,0 +1,286 @@
+package core
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// MP4Info is what we read from an ISO-BMFF (MP4/M4A/MOV) container.
+type MP4Info struct {
+	Duration     float64 // Seconds
+	CreationTime time.Time
+	Tags         map[string]string
+
+	Width      int
+	Height     int
+	VideoCodec string // Sample entry fourcc of the first video track (avc1, hvc1, ...)
+	AudioCodec string
+}
+
+// MP4 times count seconds from 1904-01-01 UTC
+var mp4Epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// mp4Box is one atom; Start/End bound its payload.
+type mp4Box struct {
+	Type  string
+	Start int64
+	End   int64
+}
+
+// Apple ilst item atoms -> our metadata keys
+var mp4TagNames = map[string]string{
+	"\xa9nam": "title",
+	"\xa9ART": "artist",
+	"aART":    "album_artist",
+	"\xa9alb": "album",
+	"\xa9day": "date",
+	"\xa9gen": "genre",
+	"\xa9cmt": "comment",
+}
+
+// ReadMP4 walks the moov atom. mdat (the media itself) is never read.
+func ReadMP4(path string) (*MP4Info, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	top, err := readMP4Boxes(f, 0, stat.Size())
+	if err != nil {
+		return nil, err
+	}
+	moov, ok := findBox(top, "moov")
+	if !ok {
+		return nil, fmt.Errorf("no moov atom")
+	}
+
+	info := &MP4Info{Tags: map[string]string{}}
+	children, err := readMP4Boxes(f, moov.Start, moov.End)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, box := range children {
+		switch box.Type {
+		case "mvhd":
+			parseMvhd(f, box, info)
+		case "udta":
+			parseUdta(f, box, info)
+		case "trak":
+			parseTrak(f, box, info)
+		}
+	}
+	return info, nil
+}
+
+func readMP4Boxes(r io.ReaderAt, start, end int64) ([]mp4Box, error) {
+	var boxes []mp4Box
+	hdr := make([]byte, 16)
+	pos := start
+
+	for pos+8 <= end && len(boxes) < 1000 {
+		if _, err := r.ReadAt(hdr[:8], pos); err != nil {
+			return boxes, err
+		}
+		size := int64(binary.BigEndian.Uint32(hdr[:4]))
+		kind := string(hdr[4:8])
+		headerLen := int64(8)
+
+		switch size {
+		case 0: // Extends to end of parent
+			size = end - pos
+		case 1: // 64-bit size follows
+			if _, err := r.ReadAt(hdr[8:16], pos+8); err != nil {
+				return boxes, err
+			}
+			size = int64(binary.BigEndian.Uint64(hdr[8:16]))
+			headerLen = 16
+		}
+		if size < headerLen || pos+size > end {
+			break
+		}
+
+		boxes = append(boxes, mp4Box{Type: kind, Start: pos + headerLen, End: pos + size})
+		pos += size
+	}
+	return boxes, nil
+}
+
+func findBox(boxes []mp4Box, kind string) (mp4Box, bool) {
+	for _, b := range boxes {
+		if b.Type == kind {
+			return b, true
+		}
+	}
+	return mp4Box{}, false
+}
+
+func readBoxPayload(r io.ReaderAt, box mp4Box, limit int64) []byte {
+	n := box.End - box.Start
+	if n <= 0 || n > limit {
+		return nil
+	}
+	buf := make([]byte, n)
+	if _, err := r.ReadAt(buf, box.Start); err != nil {
+		return nil
+	}
+	return buf
+}
+
+func parseMvhd(r io.ReaderAt, box mp4Box, info *MP4Info) {
+	data := readBoxPayload(r, box, 256)
+	if len(data) < 20 {
+		return
+	}
+
+	var created uint64
+	var timescale uint32
+	var duration uint64
+	if data[0] == 1 { // Version 1: 64-bit times
+		if len(data) < 32 {
+			return
+		}
+		created = binary.BigEndian.Uint64(data[4:])
+		timescale = binary.BigEndian.Uint32(data[20:])
+		duration = binary.BigEndian.Uint64(data[24:])
+	} else {
+		created = uint64(binary.BigEndian.Uint32(data[4:]))
+		timescale = binary.BigEndian.Uint32(data[12:])
+		duration = uint64(binary.BigEndian.Uint32(data[16:]))
+	}
+	if timescale > 0 {
+		info.Duration = float64(duration) / float64(timescale)
+	}
+	// Encoders that don't set it leave 0 (= 1904)
+	if created > 0 {
+		info.CreationTime = mp4Epoch.Add(time.Duration(created) * time.Second)
+	}
+}
+
+// parseTrak reads dimensions (tkhd), track kind (hdlr) and codec (stsd).
+func parseTrak(r io.ReaderAt, box mp4Box, info *MP4Info) {
+	children, _ := readMP4Boxes(r, box.Start, box.End)
+
+	var width, height int
+	if tkhd, ok := findBox(children, "tkhd"); ok {
+		data := readBoxPayload(r, tkhd, 256)
+		offset := 76
+		if len(data) > 0 && data[0] == 1 {
+			offset = 88
+		}
+		if len(data) >= offset+8 {
+			// 16.16 fixed point
+			width = int(binary.BigEndian.Uint32(data[offset:]) >> 16)
+			height = int(binary.BigEndian.Uint32(data[offset+4:]) >> 16)
+		}
+	}
+
+	mdia, ok := findBox(children, "mdia")
+	if !ok {
+		return
+	}
+	mdiaChildren, _ := readMP4Boxes(r, mdia.Start, mdia.End)
+
+	handler := ""
+	if hdlr, ok := findBox(mdiaChildren, "hdlr"); ok {
+		if data := readBoxPayload(r, hdlr, 1024); len(data) >= 12 {
+			handler = string(data[8:12])
+		}
+	}
+
+	codec := ""
+	if minf, ok := findBox(mdiaChildren, "minf"); ok {
+		minfChildren, _ := readMP4Boxes(r, minf.Start, minf.End)
+		if stbl, ok := findBox(minfChildren, "stbl"); ok {
+			stblChildren, _ := readMP4Boxes(r, stbl.Start, stbl.End)
+			if stsd, ok := findBox(stblChildren, "stsd"); ok {
+				// version/flags + entry count, then the first sample entry header
+				head := make([]byte, 16)
+				if _, err := r.ReadAt(head, stsd.Start); err == nil {
+					codec = strings.TrimSpace(string(head[12:16]))
+				}
+			}
+		}
+	}
+
+	switch handler {
+	case "vide":
+		if info.VideoCodec == "" {
+			info.VideoCodec = codec
+			info.Width = width
+			info.Height = height
+		}
+	case "soun":
+		if info.AudioCodec == "" {
+			info.AudioCodec = codec
+		}
+	}
+}
+
+func parseUdta(r io.ReaderAt, box mp4Box, info *MP4Info) {
+	children, _ := readMP4Boxes(r, box.Start, box.End)
+
+	// QuickTime (.mov) keeps text atoms directly in udta: size, language, string
+	if nam, ok := findBox(children, "\xa9nam"); ok {
+		if data := readBoxPayload(r, nam, 4096); len(data) > 4 {
+			info.Tags["title"] = strings.TrimSpace(string(data[4:]))
+		}
+	}
+
+	meta, ok := findBox(children, "meta")
+	if !ok {
+		return
+	}
+
+	// iTunes 'meta' is a full box (4 bytes version/flags), QuickTime's is not
+	start := meta.Start
+	probe := make([]byte, 8)
+	if _, err := r.ReadAt(probe, start); err == nil && string(probe[4:8]) != "hdlr" {
+		start += 4
+	}
+
+	metaChildren, _ := readMP4Boxes(r, start, meta.End)
+	ilst, ok := findBox(metaChildren, "ilst")
+	if !ok {
+		return
+	}
+
+	items, _ := readMP4Boxes(r, ilst.Start, ilst.End)
+	for _, item := range items {
+		key, known := mp4TagNames[item.Type]
+		if !known && item.Type != "gnre" {
+			continue
+		}
+		parts, _ := readMP4Boxes(r, item.Start, item.End)
+		data, ok := findBox(parts, "data")
+		if !ok {
+			continue
+		}
+		payload := readBoxPayload(r, data, 64*1024)
+		if len(payload) < 8 {
+			continue
+		}
+		value := payload[8:] // Skip type indicator + locale
+
+		if item.Type == "gnre" {
+			// Legacy genre: ID3v1 index + 1
+			if len(value) >= 2 {
+				if g := id3Genre(int(binary.BigEndian.Uint16(value)) - 1); g != "" {
+					info.Tags["genre"] = g
+				}
+			}
+			continue
+		}
+		info.Tags[key] = strings.TrimSpace(string(value))
+	}
+}
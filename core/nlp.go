@@ -2,6 +2,8 @@ package core
 
 import (
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -12,6 +14,9 @@ import (
 
 var w *when.Parser
 
+// "from 2023" / "in 2023". A bare year stays in the query, it may be part of a filename.
+var yearPhraseRegex = regexp.MustCompile(`\b(?:from|in) ((?:19|20)\d{2})\b`)
+
 func InitNLP() {
 	fmt.Println("[NLP] Initializing Rules...")
 	w = when.New(nil)
@@ -69,6 +74,16 @@ func manualFallback(query string) (string, int64, int64) {
 		return strings.TrimSpace(clean), start.Unix(), end.Unix()
 	}
 
+	// 4. "From 2023"
+	if m := yearPhraseRegex.FindStringSubmatchIndex(lower); m != nil {
+		year, _ := strconv.Atoi(lower[m[2]:m[3]])
+		start := time.Date(year, 1, 1, 0, 0, 0, 0, now.Location())
+		end := start.AddDate(1, 0, 0).Add(-1 * time.Second)
+
+		clean := strings.Join(strings.Fields(lower[:m[0]]+lower[m[1]:]), " ")
+		return clean, start.Unix(), end.Unix()
+	}
+
 	return query, 0, 0
 }
 
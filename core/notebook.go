@@ -0,0 +1,168 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const MaxNotebookSize = 20 * 1024 * 1024 // Notebooks with plots get big; the text part rarely is
+const MaxNotebookOutput = 2 * 1024       // Per cell
+const MaxNotebookText = 1024 * 1024
+
+// nbSource is a Jupyter multiline string: either "text" or ["line\n", ...]
+type nbSource string
+
+func (s *nbSource) UnmarshalJSON(data []byte) error {
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err == nil {
+		*s = nbSource(strings.Join(lines, ""))
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	*s = nbSource(text)
+	return nil
+}
+
+type nbOutput struct {
+	OutputType string                     `json:"output_type"`
+	Text       nbSource                   `json:"text"`
+	Data       map[string]json.RawMessage `json:"data"` // Bundles like application/json hold objects
+	Ename      string                     `json:"ename"`
+	Evalue     string                     `json:"evalue"`
+}
+
+type nbCell struct {
+	CellType string     `json:"cell_type"`
+	Source   nbSource   `json:"source"`
+	Outputs  []nbOutput `json:"outputs"`
+}
+
+type notebook struct {
+	Cells    []nbCell `json:"cells"`
+	Metadata struct {
+		Kernelspec struct {
+			Language    string `json:"language"`
+			DisplayName string `json:"display_name"`
+		} `json:"kernelspec"`
+		LanguageInfo struct {
+			Name string `json:"name"`
+		} `json:"language_info"`
+	} `json:"metadata"`
+}
+
+// Inline images in markdown (![](data:image/png;base64,...)) and other long base64 runs
+var base64Regex = regexp.MustCompile(`data:[\w.+-]+/[\w.+-]+;base64,[A-Za-z0-9+/=]+|[A-Za-z0-9+/]{200,}={0,2}`)
+
+// readNotebookContent indexes markdown cells as prose, code cells as code and
+// text outputs (truncated). Each cell becomes a segment located as "cell N".
+func readNotebookContent(path string) Extraction {
+	res := newExtraction("")
+
+	stat, err := os.Stat(path)
+	if err != nil || stat.Size() > MaxNotebookSize {
+		return res
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return res
+	}
+
+	var nb notebook
+	if err := json.Unmarshal(data, &nb); err != nil {
+		return res
+	}
+
+	lang := nb.Metadata.LanguageInfo.Name
+	if lang == "" {
+		lang = nb.Metadata.Kernelspec.Language
+	}
+	res.Metadata.Add("language", lang)
+	res.Metadata.Add("kernel", nb.Metadata.Kernelspec.DisplayName)
+	res.Metadata.Add("cells", strconv.Itoa(len(nb.Cells)))
+
+	var sb strings.Builder
+	for i, cell := range nb.Cells {
+		text := notebookCellText(cell)
+		if text == "" {
+			continue
+		}
+
+		if sb.Len() > 0 {
+			sb.WriteByte(' ')
+		}
+		res.Segments = append(res.Segments, Segment{
+			Start:   sb.Len(),
+			Locator: fmt.Sprintf("cell %d", i+1),
+			Code:    cell.CellType == "code",
+		})
+		sb.WriteString(text)
+
+		if sb.Len() > MaxNotebookText {
+			break
+		}
+	}
+
+	res.Text = sb.String()
+	return res
+}
+
+func notebookCellText(cell nbCell) string {
+	switch cell.CellType {
+	case "markdown", "code", "raw":
+	default:
+		return ""
+	}
+
+	parts := []string{base64Regex.ReplaceAllString(string(cell.Source), " ")}
+
+	outputLen := 0
+	for _, out := range cell.Outputs {
+		var text string
+		switch out.OutputType {
+		case "stream":
+			text = string(out.Text)
+		case "execute_result", "display_data":
+			// Only the plain-text representation; image/png etc. are base64
+			var plain nbSource
+			if raw, ok := out.Data["text/plain"]; ok && json.Unmarshal(raw, &plain) == nil {
+				text = string(plain)
+			}
+		case "error":
+			text = out.Ename + ": " + out.Evalue
+		}
+		text = base64Regex.ReplaceAllString(text, " ")
+
+		if remaining := MaxNotebookOutput - outputLen; len(text) > remaining {
+			text = truncateUTF8(text, remaining)
+		}
+		outputLen += len(text)
+		parts = append(parts, text)
+		if outputLen >= MaxNotebookOutput {
+			break
+		}
+	}
+
+	return cleanText(strings.Join(parts, " "), false)
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a rune.
+func truncateUTF8(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
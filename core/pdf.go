@@ -0,0 +1,154 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ledongthuc/pdf"
+)
+
+const MaxPdfTextSize = 4 * 1024 * 1024 // 4 MB of extracted text
+const DefaultMaxPdfPages = 500
+const PdfTimeout = 30 * time.Second // Long reports need more than FileTimeout
+
+// readPdfContent extracts every page (up to max_pdf_pages) and records where
+// each page starts in the text, so chunks and snippets can cite "p. 12".
+func readPdfContent(path string) (res Extraction) {
+	res = newExtraction("")
+	defer func() { recover() }()
+
+	f, r, err := pdf.Open(path)
+	if err != nil {
+		return res
+	}
+	defer f.Close()
+
+	var sb strings.Builder
+
+	// Document Info dictionary goes first, outside any page
+	info := readPdfInfo(r.Trailer().Key("Info"), &res)
+	sb.WriteString(info)
+
+	limit := r.NumPage()
+	res.Metadata.Add("pages", strconv.Itoa(limit))
+	maxPages := CurrentSettings.MaxPdfPages
+	if maxPages <= 0 {
+		maxPages = DefaultMaxPdfPages
+	}
+	if limit > maxPages {
+		limit = maxPages
+	}
+
+	for i := 1; i <= limit; i++ {
+		// PDF extractor returns Plain Text, so isXml = false
+		text := cleanText(pdfPageText(r, i), false)
+		if text == "" {
+			continue
+		}
+
+		if sb.Len() > 0 {
+			sb.WriteByte(' ')
+		}
+		res.Segments = append(res.Segments, Segment{Start: sb.Len(), Locator: fmt.Sprintf("p. %d", i)})
+		sb.WriteString(text)
+
+		if sb.Len() > MaxPdfTextSize {
+			break
+		}
+	}
+
+	res.Text = sb.String()
+	return res
+}
+
+// pdfPageText extracts one page. A malformed page can make the parser panic;
+// it is skipped and the pages before and after it are kept.
+func pdfPageText(r *pdf.Reader, i int) (text string) {
+	defer func() {
+		if recover() != nil {
+			text = ""
+		}
+	}()
+	p := r.Page(i)
+	if p.V.IsNull() {
+		return ""
+	}
+	text, _ = p.GetPlainText(nil)
+	return text
+}
+
+// readPdfInfo stores title/author/subject/keywords/creation date and returns
+// them as a searchable line.
+func readPdfInfo(info pdf.Value, res *Extraction) string {
+	if info.IsNull() {
+		return ""
+	}
+
+	var desc []string
+	add := func(key, label, value string) {
+		value = cleanText(value, false)
+		if value == "" {
+			return
+		}
+		res.Metadata.Add(key, value)
+		desc = append(desc, label+": "+value)
+	}
+
+	add("title", "Title", info.Key("Title").Text())
+	add("author", "Author", info.Key("Author").Text())
+	add("subject", "Subject", info.Key("Subject").Text())
+
+	if keywords := info.Key("Keywords").Text(); keywords != "" {
+		for _, kw := range strings.FieldsFunc(keywords, func(r rune) bool { return r == ',' || r == ';' }) {
+			res.Metadata.Add("keyword", cleanText(kw, false))
+		}
+		desc = append(desc, "Keywords: "+cleanText(keywords, false))
+	}
+
+	if created := parsePdfDate(info.Key("CreationDate").Text()); !created.IsZero() {
+		res.Metadata.Add("created", created.Format(time.RFC3339))
+		res.ContentTime = created.Unix()
+	}
+
+	return strings.Join(desc, " ")
+}
+
+var pdfDateRegex = regexp.MustCompile(`^D?:?(\d{4})(\d{2})?(\d{2})?(\d{2})?(\d{2})?(\d{2})?([Zz]|[+-]\d{2}'?\d{2}?'?)?`)
+
+// parsePdfDate reads "D:YYYYMMDDHHmmSSOHH'mm'"; every part after the year is optional.
+func parsePdfDate(value string) time.Time {
+	m := pdfDateRegex.FindStringSubmatch(strings.TrimSpace(value))
+	if m == nil {
+		return time.Time{}
+	}
+
+	num := func(s string, def int) int {
+		if n, err := strconv.Atoi(s); err == nil {
+			return n
+		}
+		return def
+	}
+
+	loc := time.Local
+	if tz := m[7]; tz != "" {
+		if tz == "Z" || tz == "z" {
+			loc = time.UTC
+		} else {
+			digits := strings.ReplaceAll(tz[1:], "'", "")
+			offset := num(digits[:2], 0)*3600 + num(digits[2:], 0)*60
+			if tz[0] == '-' {
+				offset = -offset
+			}
+			loc = time.FixedZone("", offset)
+		}
+	}
+
+	year := num(m[1], 0)
+	if year < 1900 {
+		return time.Time{}
+	}
+	return time.Date(year, time.Month(num(m[2], 1)), num(m[3], 1), num(m[4], 0), num(m[5], 0), num(m[6], 0), 0, loc)
+}
@@ -0,0 +1,265 @@
+package core
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParsedQuery is a raw search box query split into its parts.
+type ParsedQuery struct {
+	Text    string // Boolean text for FTS: terms, "phrases" and OR
+	Plain   string // The same words without operators, for embeddings and name boosts
+	Filters QueryFilters
+}
+
+// Placeholders that survive the date/duration parsers (which may lowercase the query)
+const (
+	phraseMark = "\x01"
+	orMark     = "\x02"
+)
+
+var phraseRegex = regexp.MustCompile(`(^|\s)(-?)"([^"]*)"`)
+var orRegex = regexp.MustCompile(`(^|\s)OR(\s|$)`)
+
+// ParseQuery understands field filters (ext:pdf, path:Projects, kind:image,
+// size:>10MB, modified:2024-01..2024-03, artist:...), natural dates and
+// durations, "quoted phrases", OR between terms and -term negation.
+func ParseQuery(raw string) ParsedQuery {
+	query, fields := ParseFieldFilters(raw)
+
+	// Hide phrases and OR from the NLP parsers
+	var phrases []string
+	query = phraseRegex.ReplaceAllStringFunc(query, func(token string) string {
+		m := phraseRegex.FindStringSubmatch(token)
+		phrases = append(phrases, m[3])
+		return m[1] + m[2] + phraseMark + strconv.Itoa(len(phrases)-1) + phraseMark
+	})
+	query = orRegex.ReplaceAllString(query, "$1"+orMark+"$2")
+
+	query, minDur, maxDur := ParseDurationFilter(query)
+	query, minTime, maxTime := ParseDateQuery(query)
+
+	filters := QueryFilters{
+		MinTime:     minTime,
+		MaxTime:     maxTime,
+		Fields:      fields,
+		MinDuration: minDur,
+		MaxDuration: maxDur,
+	}
+
+	var text, plain []string
+	pendingOr := false
+	for _, token := range strings.Fields(query) {
+		if token == orMark {
+			pendingOr = len(text) > 0
+			continue
+		}
+
+		negate := strings.HasPrefix(token, "-") && len(token) > 1
+		if negate {
+			token = token[1:]
+		}
+
+		word := token
+		if strings.HasPrefix(token, phraseMark) && strings.HasSuffix(token, phraseMark) && len(token) > 2 {
+			if i, err := strconv.Atoi(strings.Trim(token, phraseMark)); err == nil && i < len(phrases) {
+				word = phrases[i]
+				token = `"` + phrases[i] + `"`
+			}
+		}
+
+		if negate {
+			filters.Exclude = append(filters.Exclude, token)
+			pendingOr = false
+			continue
+		}
+		if pendingOr {
+			text = append(text, "OR")
+			pendingOr = false
+		}
+		text = append(text, token)
+		plain = append(plain, word)
+	}
+
+	return ParsedQuery{
+		Text:    strings.Join(text, " "),
+		Plain:   strings.Join(strings.Fields(strings.Join(plain, " ")), " "),
+		Filters: filters,
+	}
+}
+
+// textAlt is one alternative of a query group: loose words or an exact phrase.
+type textAlt struct {
+	Words  []string
+	Phrase bool
+}
+
+var textTokenRegex = regexp.MustCompile(`"[^"]*"|\S+`)
+
+// parseTextGroups reads boolean text into AND-ed groups of OR-ed alternatives.
+// Words are lowercased and stripped to letters/digits like the FTS tokenizer.
+func parseTextGroups(text string) [][]textAlt {
+	var groups [][]textAlt
+	joinNext := false
+	for _, token := range textTokenRegex.FindAllString(text, -1) {
+		if token == "OR" {
+			joinNext = len(groups) > 0
+			continue
+		}
+		phrase := strings.HasPrefix(token, `"`)
+		words := strings.Fields(strings.ToLower(queryCleaner.ReplaceAllString(strings.Trim(token, `"`), " ")))
+		if len(words) == 0 {
+			continue
+		}
+		alt := textAlt{Words: words, Phrase: phrase}
+		if joinNext {
+			groups[len(groups)-1] = append(groups[len(groups)-1], alt)
+			joinNext = false
+		} else {
+			groups = append(groups, []textAlt{alt})
+		}
+	}
+	return groups
+}
+
+// ftsExpression renders groups as an FTS5 MATCH expression. Loose words are
+// prefix matches passed through stem; phrases are matched exactly.
+func ftsExpression(groups [][]textAlt, stem func(string) string) string {
+	var parts []string
+	for _, group := range groups {
+		var alts []string
+		for _, alt := range group {
+			if alt.Phrase {
+				alts = append(alts, `"`+strings.Join(alt.Words, " ")+`"`)
+				continue
+			}
+			words := make([]string, len(alt.Words))
+			for i, w := range alt.Words {
+				words[i] = stem(w) + "*"
+			}
+			expr := strings.Join(words, " AND ")
+			if len(words) > 1 && len(group) > 1 {
+				expr = "(" + expr + ")"
+			}
+			alts = append(alts, expr)
+		}
+		if len(alts) > 1 {
+			parts = append(parts, "("+strings.Join(alts, " OR ")+")")
+		} else {
+			parts = append(parts, alts[0])
+		}
+	}
+	return strings.Join(parts, " AND ")
+}
+
+// --- SIZE / DATE RANGES ---
+
+var sizeRegex = regexp.MustCompile(`(?i)^(\d+(?:\.\d+)?)\s*(b|k|kb|m|mb|g|gb|t|tb)?$`)
+
+var sizeUnits = map[string]float64{
+	"": 1, "b": 1,
+	"k": 1 << 10, "kb": 1 << 10,
+	"m": 1 << 20, "mb": 1 << 20,
+	"g": 1 << 30, "gb": 1 << 30,
+	"t": 1 << 40, "tb": 1 << 40,
+}
+
+func parseSize(s string) (int64, bool) {
+	m := sizeRegex.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0, false
+	}
+	n, _ := strconv.ParseFloat(m[1], 64)
+	return int64(n * sizeUnits[strings.ToLower(m[2])]), true
+}
+
+// valueRange holds inclusive bounds; a side without its Has flag is unbounded.
+type valueRange struct {
+	Min, Max       int64
+	HasMin, HasMax bool
+}
+
+// parseSizeRange reads ">10MB", "<=1GB", "10MB..100MB", "10MB" (at least) or
+// "0" (empty files). Bounds are in bytes.
+func parseSizeRange(value string) (valueRange, bool) {
+	r, ok := parseRange(value, func(s string, _ bool) (int64, bool) { return parseSize(s) })
+	if ok && r.HasMin && r.Min == 0 && !strings.ContainsAny(value, "<>.") {
+		r.Max, r.HasMax = 0, true
+	}
+	return r, ok
+}
+
+// parseDateRange reads "2024", "2024-03", "2024-03-05", ">2024-01", "2024-01..2024-03"
+// as Unix bounds, each side covering its whole year/month/day.
+func parseDateRange(value string) (valueRange, bool) {
+	r, ok := parseRange(value, parseDateBound)
+	if ok && !strings.ContainsAny(value, "<>.") {
+		// A single date is the whole period it names
+		r.Max, _ = parseDateBound(value, true)
+		r.HasMax = true
+	}
+	return r, ok
+}
+
+// parseDateBound returns the first (or, with end, the last) second of a period.
+func parseDateBound(s string, end bool) (int64, bool) {
+	for _, layout := range []struct {
+		Format string
+		Years  int
+		Months int
+		Days   int
+	}{{"2006-01-02", 0, 0, 1}, {"2006-01", 0, 1, 0}, {"2006", 1, 0, 0}} {
+		t, err := time.ParseInLocation(layout.Format, strings.TrimSpace(s), time.Local)
+		if err != nil {
+			continue
+		}
+		if end {
+			t = t.AddDate(layout.Years, layout.Months, layout.Days).Add(-time.Second)
+		}
+		return t.Unix(), true
+	}
+	return 0, false
+}
+
+// parseRange handles the comparison/range syntax shared by size: and modified:.
+func parseRange(value string, parse func(s string, end bool) (int64, bool)) (valueRange, bool) {
+	var r valueRange
+	switch {
+	case strings.Contains(value, ".."):
+		parts := strings.SplitN(value, "..", 2)
+		if parts[0] != "" {
+			v, ok := parse(parts[0], false)
+			if !ok {
+				return r, false
+			}
+			r.Min, r.HasMin = v, true
+		}
+		if parts[1] != "" {
+			v, ok := parse(parts[1], true)
+			if !ok {
+				return r, false
+			}
+			r.Max, r.HasMax = v, true
+		}
+		return r, r.HasMin || r.HasMax
+	case strings.HasPrefix(value, ">="), strings.HasPrefix(value, ">"):
+		v, ok := parse(strings.TrimLeft(value, ">="), strings.HasPrefix(value, ">") && !strings.HasPrefix(value, ">="))
+		if ok && !strings.HasPrefix(value, ">=") {
+			v++
+		}
+		r.Min, r.HasMin = v, ok
+		return r, ok
+	case strings.HasPrefix(value, "<="), strings.HasPrefix(value, "<"):
+		v, ok := parse(strings.TrimLeft(value, "<="), strings.HasPrefix(value, "<="))
+		if ok && !strings.HasPrefix(value, "<=") {
+			v--
+		}
+		r.Max, r.HasMax = v, ok
+		return r, ok
+	}
+	v, ok := parse(value, false)
+	r.Min, r.HasMin = v, ok
+	return r, ok
+}
@@ -0,0 +1,87 @@
+package core
+
+import (
+	"math"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultRRFK is the usual Reciprocal Rank Fusion constant: larger values
+// flatten the difference between the first and the tenth hit of a source.
+const DefaultRRFK = 60
+
+// RankingSettings tune how HybridSearch fuses its sources. Each source
+// contributes weight/(k+rank) for every file it returns; a weight of 0
+// turns the source off. Boosts multiply the fused score by 1+boost*feature.
+type RankingSettings struct {
+	RRFK float64 `json:"rrf_k"`
+
+	KeywordWeight  float64 `json:"keyword_weight"`
+	SemanticWeight float64 `json:"semantic_weight"`
+	FuzzyWeight    float64 `json:"fuzzy_weight"`
+	AcronymWeight  float64 `json:"acronym_weight"`
+	UsageWeight    float64 `json:"usage_weight"`    // Matches ranked by how often and recently they were opened
+	AffinityWeight float64 `json:"affinity_weight"` // Files ranked by how often they were picked for this query
+
+	UsageHalfLifeDays float64 `json:"usage_half_life_days"` // An open counts half as much after this many days
+
+	AppBoost        float64 `json:"app_boost"`         // .lnk / .exe / .desktop entries
+	NamePrefixBoost float64 `json:"name_prefix_boost"` // Filename starts with the query
+	BacklinkBoost   float64 `json:"backlink_boost"`    // Per log(1+backlinks) of a note
+}
+
+func defaultRankingSettings() RankingSettings {
+	return RankingSettings{
+		RRFK:              DefaultRRFK,
+		KeywordWeight:     1.2,
+		SemanticWeight:    1.0,
+		FuzzyWeight:       0.8,
+		AcronymWeight:     1.0,
+		UsageWeight:       1.0,
+		AffinityWeight:    2.0,
+		UsageHalfLifeDays: DefaultUsageHalfLifeDays,
+		AppBoost:          1.0,
+		NamePrefixBoost:   0.5,
+		BacklinkBoost:     0.1,
+	}
+}
+
+func (r RankingSettings) k() float64 {
+	if r.RRFK <= 0 {
+		return DefaultRRFK
+	}
+	return r.RRFK
+}
+
+func (r RankingSettings) halfLifeDays() float64 {
+	if r.UsageHalfLifeDays <= 0 {
+		return DefaultUsageHalfLifeDays
+	}
+	return r.UsageHalfLifeDays
+}
+
+// FeatureBoosts are the multipliers one result got from the boost features (1 = none).
+type FeatureBoosts struct {
+	App        float32
+	NamePrefix float32
+	Backlinks  float32
+}
+
+func (b FeatureBoosts) product() float32 {
+	return b.App * b.NamePrefix * b.Backlinks
+}
+
+// featureBoosts evaluates the boost features for one result.
+func (r RankingSettings) featureBoosts(path, query string, backlinks float32) FeatureBoosts {
+	boosts := FeatureBoosts{App: 1, NamePrefix: 1, Backlinks: 1}
+	if isAppPath(path) {
+		boosts.App = float32(1 + r.AppBoost)
+	}
+	if query != "" && strings.HasPrefix(strings.ToLower(filepath.Base(path)), strings.ToLower(query)) {
+		boosts.NamePrefix = float32(1 + r.NamePrefixBoost)
+	}
+	if backlinks > 0 {
+		boosts.Backlinks = float32(1 + r.BacklinkBoost*math.Log1p(float64(backlinks)))
+	}
+	return boosts
+}
@@ -0,0 +1,292 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SavedSearch is a named query, recalled by typing its name or !alias.
+// The query is stored as typed, so "last month" is re-evaluated on every run.
+type SavedSearch struct {
+	Name         string
+	Alias        string // Without the leading "!"; may be empty
+	Query        string
+	CreatedAt    int64
+	LastOpenedAt int64
+	NewCount     int // Matches not shown when the search was last opened; see CountNewMatches
+}
+
+// setupSavedSearches creates the saved search tables.
+func setupSavedSearches() {
+	DB.Exec(`CREATE TABLE IF NOT EXISTS saved_searches (
+		id INTEGER PRIMARY KEY,
+		name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+		alias TEXT NOT NULL DEFAULT '' COLLATE NOCASE,
+		query TEXT NOT NULL,
+		created_at INTEGER NOT NULL,
+		last_opened_at INTEGER NOT NULL DEFAULT 0
+	);`)
+	DB.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_saved_alias ON saved_searches(alias) WHERE alias != '';`)
+	// Paths a saved search matched when it was last opened; any other match is new
+	DB.Exec(`CREATE TABLE IF NOT EXISTS saved_search_seen (
+		search_id INTEGER NOT NULL,
+		path TEXT NOT NULL,
+		PRIMARY KEY (search_id, path)
+	);`)
+}
+
+// SaveSearch stores query under name (replacing a search of the same name).
+// Its current matches become the baseline for reporting new ones.
+func SaveSearch(ctx context.Context, name, alias, query string) error {
+	name = strings.TrimSpace(name)
+	alias = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(alias)), "!")
+	query = strings.TrimSpace(query)
+	switch {
+	case name == "" || strings.HasPrefix(name, "!"):
+		return fmt.Errorf("a saved search needs a name that does not start with '!'")
+	case strings.ContainsAny(alias, " \t!"):
+		return fmt.Errorf("alias %q must be a single word", alias)
+	case query == "":
+		return fmt.Errorf("saved search %q has no query", name)
+	}
+
+	_, err := DB.ExecContext(ctx, `
+		INSERT INTO saved_searches (name, alias, query, created_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET alias = excluded.alias, query = excluded.query`,
+		name, alias, query, time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("saving %q: %w", name, err)
+	}
+	_, err = OpenSavedSearch(ctx, name)
+	return err
+}
+
+// DeleteSavedSearch removes a saved search and what it had seen.
+func DeleteSavedSearch(name string) error {
+	_, err := DB.Exec(`DELETE FROM saved_search_seen WHERE search_id = (SELECT id FROM saved_searches WHERE name = ?)`, name)
+	if err == nil {
+		_, err = DB.Exec(`DELETE FROM saved_searches WHERE name = ?`, name)
+	}
+	return err
+}
+
+// ResolveSavedSearch finds the saved search the launcher input refers to:
+// "!alias" or the exact name (case-insensitive).
+func ResolveSavedSearch(input string) (SavedSearch, bool) {
+	input = strings.TrimSpace(input)
+	column := "name"
+	if strings.HasPrefix(input, "!") {
+		column, input = "alias", input[1:]
+	}
+	if input == "" {
+		return SavedSearch{}, false
+	}
+
+	var s SavedSearch
+	err := DB.QueryRow(`SELECT name, alias, query, created_at, last_opened_at FROM saved_searches WHERE `+column+` = ?`, input).
+		Scan(&s.Name, &s.Alias, &s.Query, &s.CreatedAt, &s.LastOpenedAt)
+	return s, err == nil
+}
+
+// ListSavedSearches returns all saved searches by name. It only reads the
+// table; NewCount is left at 0.
+func ListSavedSearches(ctx context.Context) ([]SavedSearch, error) {
+	rows, err := DB.QueryContext(ctx, `SELECT name, alias, query, created_at, last_opened_at FROM saved_searches ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	var list []SavedSearch
+	for rows.Next() {
+		var s SavedSearch
+		if rows.Scan(&s.Name, &s.Alias, &s.Query, &s.CreatedAt, &s.LastOpenedAt) == nil {
+			list = append(list, s)
+		}
+	}
+	rows.Close()
+	return list, nil
+}
+
+// CountNewMatches fills in NewCount, running every search in list.
+func CountNewMatches(ctx context.Context, list []SavedSearch) error {
+	for i := range list {
+		fresh, err := NewMatches(ctx, list[i].Name)
+		if err != nil {
+			return err
+		}
+		list[i].NewCount = len(fresh)
+	}
+	return nil
+}
+
+// NewMatches returns what a saved search matches now but did not when it
+// was last opened: new files, and files a relative date range has moved onto.
+func NewMatches(ctx context.Context, name string) ([]SearchResult, error) {
+	var id int
+	var query string
+	if err := DB.QueryRowContext(ctx, `SELECT id, query FROM saved_searches WHERE name = ?`, name).Scan(&id, &query); err != nil {
+		return nil, err
+	}
+	matches, err := savedMatches(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	seen, err := seenPaths(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var fresh []SearchResult
+	for _, res := range matches {
+		if !seen[res.Path] {
+			fresh = append(fresh, res)
+		}
+	}
+	return fresh, nil
+}
+
+// OpenSavedSearch marks a saved search as opened now and returns the paths
+// that were new to it; the current matches become what it has seen.
+func OpenSavedSearch(ctx context.Context, name string) (map[string]bool, error) {
+	var id int
+	var query string
+	var lastOpened int64
+	err := DB.QueryRowContext(ctx, `SELECT id, query, last_opened_at FROM saved_searches WHERE name = ?`, name).Scan(&id, &query, &lastOpened)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := savedMatches(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	seen, err := seenPaths(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	fresh := make(map[string]bool)
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM saved_search_seen WHERE search_id = ?`, id); err != nil {
+		return nil, err
+	}
+	for _, res := range matches {
+		// The first open only records the baseline
+		if lastOpened > 0 && !seen[res.Path] {
+			fresh[res.Path] = true
+		}
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO saved_search_seen (search_id, path) VALUES (?, ?)`, id, res.Path); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := tx.Exec(`UPDATE saved_searches SET last_opened_at = ? WHERE id = ?`, time.Now().Unix(), id); err != nil {
+		return nil, err
+	}
+	return fresh, tx.Commit()
+}
+
+// savedMatches returns everything a saved query matches: the ranking the
+// launcher shows for it (so fuzzy and abbreviation hits count), then every
+// other keyword/filter match and every chunk above the semantic threshold,
+// without a rank cutoff. Dates are parsed again here, so "from last month"
+// always means the month before today.
+func savedMatches(ctx context.Context, query string) ([]SearchResult, error) {
+	page, err := HybridSearch(ctx, query, SearchOptions{Limit: MaxPageSize})
+	if err != nil {
+		return nil, err
+	}
+	results := page.Results
+	matched := make(map[string]bool, len(results))
+	for _, res := range results {
+		matched[res.Path] = true
+	}
+	add := func(more []SearchResult) {
+		for _, res := range more {
+			if !matched[res.Path] {
+				matched[res.Path] = true
+				results = append(results, res)
+			}
+		}
+	}
+
+	parsed := ParseQuery(query)
+	keyword, err := keywordMatches(ctx, parsed.Text, parsed.Filters)
+	if err != nil {
+		return nil, err
+	}
+	add(keyword)
+	if IsAIReady && parsed.Plain != "" && CurrentSettings.Ranking.SemanticWeight > 0 {
+		semantic, _ := SemanticSearch(ctx, parsed.Plain, parsed.Filters, len(VectorIndex))
+		add(semantic)
+	}
+	return results, ctx.Err()
+}
+
+// keywordMatches lists every file the text and filters match, like
+// SearchFiles but unranked and without snippets or a limit.
+func keywordMatches(ctx context.Context, text string, filters QueryFilters) ([]SearchResult, error) {
+	clauses, clauseArgs := filters.sqlClauses()
+	groups := parseTextGroups(text)
+
+	var queries []string
+	var argSets [][]interface{}
+	if len(groups) == 0 {
+		if !filters.HasFields() {
+			return nil, nil
+		}
+		queries = append(queries, "SELECT f.path, f.extension FROM files f WHERE 1 = 1 "+clauses)
+		argSets = append(argSets, clauseArgs)
+	} else {
+		// Stemmed variants only match documents stored in that language
+		base := "SELECT f.path, f.extension FROM files_fts JOIN files f ON f.id = files_fts.rowid WHERE files_fts MATCH ? " + clauses
+		plain := ftsExpression(groups, func(w string) string { return w })
+		queries = append(queries, base)
+		argSets = append(argSets, append([]interface{}{plain}, clauseArgs...))
+		for _, lang := range []string{"en", "de", "hi"} {
+			stemmed := ftsExpression(groups, func(w string) string { return StemTerm(lang, w) })
+			if stemmed == plain {
+				continue
+			}
+			queries = append(queries, base+" AND f.lang = ? ")
+			argSets = append(argSets, append(append([]interface{}{stemmed}, clauseArgs...), lang))
+		}
+	}
+
+	var results []SearchResult
+	seen := make(map[string]bool)
+	for i, query := range queries {
+		rows, err := DB.QueryContext(ctx, query, argSets[i]...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var res SearchResult
+			if rows.Scan(&res.Path, &res.Extension) == nil && !seen[res.Path] {
+				seen[res.Path] = true
+				results = append(results, res)
+			}
+		}
+		rows.Close()
+	}
+	return results, nil
+}
+
+func seenPaths(ctx context.Context, id int) (map[string]bool, error) {
+	rows, err := DB.QueryContext(ctx, `SELECT path FROM saved_search_seen WHERE search_id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	seen := make(map[string]bool)
+	for rows.Next() {
+		var path string
+		if rows.Scan(&path) == nil {
+			seen[path] = true
+		}
+	}
+	return seen, nil
+}
@@ -0,0 +1,379 @@
+package core
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// sensitiveDetector masks one kind of secret. Group selects the part of the
+// match to mask (0 = all of it); Valid filters regex hits by checksum.
+type sensitiveDetector struct {
+	Kind  string
+	Regex *regexp.Regexp
+	Group int
+	Valid func(string) bool
+}
+
+var builtinSensitiveDetectors = []sensitiveDetector{
+	{Kind: "credit_card", Regex: regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`), Valid: validLuhn},
+	{Kind: "private_key", Regex: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?(?:-----END [A-Z ]*PRIVATE KEY-----|$)`)},
+	{Kind: "api_key", Regex: regexp.MustCompile(`\b(?:AKIA[0-9A-Z]{16}|AIza[0-9A-Za-z_-]{35}|gh[pousr]_[0-9A-Za-z]{36,}|github_pat_[0-9A-Za-z_]{22,}|xox[abprs]-[0-9A-Za-z-]{10,}|sk_live_[0-9A-Za-z]{24,}|sk-[A-Za-z0-9_-]{32,})\b`)},
+	{Kind: "password", Regex: regexp.MustCompile(`(?i)\b(?:password|passwort|passwd|pwd|passphrase|secret|api[_-]?key|access[_-]?token)\s*[:=]\s*["']?([^\s"']{4,})`), Group: 1},
+	{Kind: "ssn", Regex: regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`), Valid: validSSN},
+	{Kind: "aadhaar", Regex: regexp.MustCompile(`\b[2-9]\d{3}[ -]?\d{4}[ -]?\d{4}\b`), Valid: validVerhoeff},
+	{Kind: "pan", Regex: regexp.MustCompile(`\b[A-Z]{3}[PCHFATBLJG][A-Z]\d{4}[A-Z]\b`)},
+}
+
+// sensitiveDetectors returns the detectors enabled in settings plus the
+// user's own patterns. Invalid custom patterns are reported and skipped.
+func sensitiveDetectors() []sensitiveDetector {
+	if !CurrentSettings.SensitiveDetection {
+		return nil
+	}
+
+	var detectors []sensitiveDetector
+	for _, d := range builtinSensitiveDetectors {
+		if len(CurrentSettings.SensitiveKinds) == 0 || containsString(CurrentSettings.SensitiveKinds, d.Kind) {
+			detectors = append(detectors, d)
+		}
+	}
+
+	kinds := make([]string, 0, len(CurrentSettings.SensitivePatterns))
+	for kind := range CurrentSettings.SensitivePatterns {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		re, err := regexp.Compile(CurrentSettings.SensitivePatterns[kind])
+		if err != nil {
+			fmt.Printf("Invalid sensitive pattern %q: %v\n", kind, err)
+			continue
+		}
+		detectors = append(detectors, sensitiveDetector{Kind: kind, Regex: re})
+	}
+	return detectors
+}
+
+// RedactSensitive finds secrets in text and returns the text with them masked
+// (when redaction is on) and the number of hits per kind. Masking keeps the
+// byte length, so segment offsets stay valid; card numbers keep their last 4 digits.
+func RedactSensitive(detectors []sensitiveDetector, text string) (string, map[string]int) {
+	if len(detectors) == 0 || text == "" {
+		return text, nil
+	}
+
+	var found map[string]int
+	masked := []byte(text)
+	for _, d := range detectors {
+		for _, m := range d.Regex.FindAllStringSubmatchIndex(text, -1) {
+			start, end := m[2*d.Group], m[2*d.Group+1]
+			if start < 0 {
+				continue
+			}
+			if d.Valid != nil && !d.Valid(text[start:end]) {
+				continue
+			}
+			if found == nil {
+				found = make(map[string]int)
+			}
+			found[d.Kind]++
+
+			if !CurrentSettings.RedactSensitive {
+				continue
+			}
+			keep := 0
+			if d.Kind == "credit_card" {
+				keep = 4
+			}
+			for i, digits := end-1, 0; i >= start; i-- {
+				if masked[i] >= '0' && masked[i] <= '9' && digits < keep {
+					digits++
+					continue
+				}
+				if masked[i] != ' ' && masked[i] != '-' && masked[i] != '\n' {
+					masked[i] = '*'
+				}
+			}
+		}
+	}
+	return string(masked), found
+}
+
+// validLuhn checks a card number (separators allowed) with the Luhn checksum.
+func validLuhn(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && n <= 19 && sum%10 == 0
+}
+
+// validSSN rejects the ranges the SSA never issues (000, 666, 9xx, 00, 0000).
+func validSSN(s string) bool {
+	area, group, serial := s[:3], s[4:6], s[7:]
+	return area != "000" && area != "666" && area[0] != '9' && group != "00" && serial != "0000"
+}
+
+// Verhoeff tables (dihedral group D5), used for the Aadhaar check digit.
+var (
+	verhoeffD = [10][10]int{
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, {1, 2, 3, 4, 0, 6, 7, 8, 9, 5},
+		{2, 3, 4, 0, 1, 7, 8, 9, 5, 6}, {3, 4, 0, 1, 2, 8, 9, 5, 6, 7},
+		{4, 0, 1, 2, 3, 9, 5, 6, 7, 8}, {5, 9, 8, 7, 6, 0, 4, 3, 2, 1},
+		{6, 5, 9, 8, 7, 1, 0, 4, 3, 2}, {7, 6, 5, 9, 8, 2, 1, 0, 4, 3},
+		{8, 7, 6, 5, 9, 3, 2, 1, 0, 4}, {9, 8, 7, 6, 5, 4, 3, 2, 1, 0},
+	}
+	verhoeffP = [8][10]int{
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, {1, 5, 7, 6, 2, 8, 3, 0, 9, 4},
+		{5, 8, 0, 3, 7, 9, 6, 1, 4, 2}, {8, 9, 1, 6, 0, 4, 3, 5, 2, 7},
+		{9, 4, 5, 3, 1, 2, 6, 8, 7, 0}, {4, 2, 8, 6, 5, 7, 3, 9, 0, 1},
+		{2, 7, 9, 3, 8, 0, 6, 4, 1, 5}, {7, 0, 4, 6, 9, 1, 3, 2, 5, 8},
+	}
+)
+
+func validVerhoeff(s string) bool {
+	c, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] < '0' || s[i] > '9' {
+			continue
+		}
+		c = verhoeffD[c][verhoeffP[n%8][int(s[i]-'0')]]
+		n++
+	}
+	return n == 12 && c == 0
+}
+
+// --- DB HELPERS ---
+
+// FlaggedFile is a file in which secrets were detected, for review.
+type FlaggedFile struct {
+	Path  string
+	Kinds map[string]int
+}
+
+// SaveSensitiveFlags replaces the detector hits recorded for a file.
+func SaveSensitiveFlags(tx *sql.Tx, fileID int, found map[string]int) error {
+	if _, err := tx.Exec("DELETE FROM sensitive_flags WHERE file_id = ?", fileID); err != nil {
+		return err
+	}
+	for kind, count := range found {
+		if _, err := tx.Exec("INSERT INTO sensitive_flags (file_id, kind, count) VALUES (?, ?, ?)", fileID, kind, count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addSensitiveFlags adds hits to those already recorded for a file.
+func addSensitiveFlags(tx *sql.Tx, fileID int, found map[string]int) error {
+	for kind, count := range found {
+		res, err := tx.Exec("UPDATE sensitive_flags SET count = count + ? WHERE file_id = ? AND kind = ?", count, fileID, kind)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			continue
+		}
+		if _, err := tx.Exec("INSERT INTO sensitive_flags (file_id, kind, count) VALUES (?, ?, ?)", fileID, kind, count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RedactMetadata masks secrets in every metadata value (in place) and
+// returns the hits per kind.
+func RedactMetadata(detectors []sensitiveDetector, meta Metadata) map[string]int {
+	var found map[string]int
+	for _, values := range meta {
+		for i, v := range values {
+			var hits map[string]int
+			values[i], hits = RedactSensitive(detectors, v)
+			found = addFound(found, hits)
+		}
+	}
+	return found
+}
+
+// addFound adds the hit counts in more to found.
+func addFound(found, more map[string]int) map[string]int {
+	for kind, n := range more {
+		if found == nil {
+			found = make(map[string]int)
+		}
+		found[kind] += n
+	}
+	return found
+}
+
+// redactStoredMetadata masks secrets in a file's stored metadata values.
+func redactStoredMetadata(tx *sql.Tx, detectors []sensitiveDetector, fileID int) map[string]int {
+	rows, err := tx.Query("SELECT rowid, value FROM file_metadata WHERE file_id = ?", fileID)
+	if err != nil {
+		return nil
+	}
+	type stored struct {
+		RowID int64
+		Value string
+	}
+	var values []stored
+	for rows.Next() {
+		var st stored
+		if rows.Scan(&st.RowID, &st.Value) == nil {
+			values = append(values, st)
+		}
+	}
+	rows.Close()
+
+	var found map[string]int
+	for _, st := range values {
+		text, hits := RedactSensitive(detectors, st.Value)
+		if text != st.Value {
+			tx.Exec("UPDATE file_metadata SET value = ? WHERE rowid = ?", text, st.RowID)
+		}
+		found = addFound(found, hits)
+	}
+	return found
+}
+
+const redactBatchSize = 500
+
+// RedactStoredSummaries runs the detectors over summaries (and their metadata)
+// stored since the last pass, which includes everything indexed before
+// redaction existed.
+// Secrets are masked in place and flagged on the file they came from. When
+// the text changed, the file's vectors are dropped so they are re-embedded
+// from the masked text; with skip_embedding_sensitive they are dropped anyway.
+func RedactStoredSummaries() {
+	detectors := sensitiveDetectors()
+	if len(detectors) == 0 {
+		return
+	}
+
+	var lastID int
+	DB.QueryRow("SELECT value FROM index_state WHERE key = 'redacted_upto'").Scan(&lastID)
+
+	// Files flagged at extraction are already masked; checking them again would count twice
+	alreadyFlagged := make(map[int]bool)
+	if rows, err := DB.Query("SELECT DISTINCT file_id FROM sensitive_flags"); err == nil {
+		for rows.Next() {
+			var id int
+			if rows.Scan(&id) == nil {
+				alreadyFlagged[id] = true
+			}
+		}
+		rows.Close()
+	}
+
+	flagged, dropped := 0, 0
+	for {
+		type stored struct {
+			ID, Owner int
+			Summary   string
+		}
+		rows, err := DB.Query(`SELECT id, COALESCE(parent_id, id), summary FROM files
+			WHERE id > ? AND summary IS NOT NULL AND summary != '' ORDER BY id LIMIT ?`, lastID, redactBatchSize)
+		if err != nil {
+			fmt.Printf("Error checking stored summaries: %v\n", err)
+			return
+		}
+		var batch []stored
+		for rows.Next() {
+			var st stored
+			if rows.Scan(&st.ID, &st.Owner, &st.Summary) == nil {
+				batch = append(batch, st)
+			}
+		}
+		rows.Close()
+		if len(batch) == 0 {
+			break
+		}
+
+		tx, err := DB.Begin()
+		if err != nil {
+			return
+		}
+		for _, st := range batch {
+			if alreadyFlagged[st.Owner] {
+				continue
+			}
+			text, found := RedactSensitive(detectors, st.Summary)
+			found = addFound(found, redactStoredMetadata(tx, detectors, st.ID))
+			if len(found) == 0 {
+				continue
+			}
+			flagged++
+			// Vectors of unchanged text stay, unless flagged files are kept out of semantic search
+			if text != st.Summary {
+				tx.Exec("UPDATE files SET summary = ? WHERE id = ?", text, st.ID)
+			}
+			if text != st.Summary || CurrentSettings.SkipEmbeddingSensitive {
+				tx.Exec("DELETE FROM file_vectors WHERE file_id = ?", st.ID)
+				dropped++
+			}
+			addSensitiveFlags(tx, st.Owner, found)
+		}
+		lastID = batch[len(batch)-1].ID
+		tx.Exec("INSERT OR REPLACE INTO index_state (key, value) VALUES ('redacted_upto', ?)", lastID)
+		if err := tx.Commit(); err != nil {
+			fmt.Printf("Error redacting stored summaries: %v\n", err)
+			return
+		}
+	}
+
+	if flagged > 0 {
+		fmt.Printf("Found secrets in %d previously indexed files\n", flagged)
+	}
+	if dropped > 0 {
+		LoadVectorIndex()
+	}
+}
+
+// markSummariesRedacted records that every summary stored so far went through
+// the detectors, e.g. at the end of a deep scan.
+func markSummariesRedacted() {
+	if len(sensitiveDetectors()) == 0 {
+		return
+	}
+	DB.Exec("INSERT OR REPLACE INTO index_state (key, value) SELECT 'redacted_upto', COALESCE(MAX(id), 0) FROM files")
+}
+
+// GetFlaggedFiles lists every file with detector hits, sorted by path.
+func GetFlaggedFiles() []FlaggedFile {
+	flagged := []FlaggedFile{}
+	rows, err := DB.Query(`
+		SELECT f.path, s.kind, s.count FROM sensitive_flags s
+		JOIN files f ON f.id = s.file_id
+		ORDER BY f.path, s.kind`)
+	if err != nil {
+		return flagged
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var path, kind string
+		var count int
+		if rows.Scan(&path, &kind, &count) != nil {
+			continue
+		}
+		if n := len(flagged); n == 0 || flagged[n-1].Path != path {
+			flagged = append(flagged, FlaggedFile{Path: path, Kinds: map[string]int{}})
+		}
+		flagged[len(flagged)-1].Kinds[kind] = count
+	}
+	return flagged
+}
@@ -16,6 +16,30 @@ type AppSettings struct {
 
 	IgnoredPaths      []string `json:"ignored_paths"`
 	AllowedExtensions []string `json:"allowed_extensions"`
+
+	// Pages extracted per PDF (0 = DefaultMaxPdfPages)
+	MaxPdfPages int `json:"max_pdf_pages"`
+
+	// Embedding model per detected language, e.g. {"de": {"name": "multilingual"}}.
+	// Languages not listed use the default all-MiniLM-L6-v2.
+	LanguageModels map[string]ModelConfig `json:"language_models"`
+
+	// Secrets (cards, API keys, passwords, national IDs) found before the
+	// summary is stored. Kinds empty = all built-in detectors; patterns add
+	// custom ones as {"kind": "regex"}.
+	SensitiveDetection     bool              `json:"sensitive_detection"`
+	RedactSensitive        bool              `json:"redact_sensitive"`
+	SkipEmbeddingSensitive bool              `json:"skip_embedding_sensitive"`
+	SensitiveKinds         []string          `json:"sensitive_kinds"`
+	SensitivePatterns      map[string]string `json:"sensitive_patterns"`
+
+	// Source weights, RRF k and boosts used to merge search results
+	Ranking RankingSettings `json:"ranking"`
+}
+
+type ModelConfig struct {
+	Name       string `json:"name"`
+	HiddenSize int    `json:"hidden_size"` // 0 = 384
 }
 
 var CurrentSettings AppSettings
@@ -32,6 +56,10 @@ func getDefaultSettings() AppSettings {
 		AllowedExtensions: []string{
 			".txt", ".md", ".markdown", ".pdf", ".docx", ".rtf",
 		},
+		MaxPdfPages:        DefaultMaxPdfPages,
+		SensitiveDetection: true,
+		RedactSensitive:    true,
+		Ranking:            defaultRankingSettings(),
 	}
 }
 
@@ -46,6 +74,8 @@ func LoadSettings() {
 	}
 	defer file.Close()
 
+	// Decode over the defaults so settings added in newer versions keep a sane value
+	CurrentSettings = getDefaultSettings()
 	decoder := json.NewDecoder(file)
 	err = decoder.Decode(&CurrentSettings)
 	if err != nil {
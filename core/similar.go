@@ -0,0 +1,255 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	similarMaxResults = 15
+	similarTerms      = 10 // Distinctive words of the file run as the keyword query
+	similarCandidates = 64 // Most frequent words looked up in the vocabulary
+	similarMinWordLen = 3
+)
+
+// Labels AnalyzeImage and the EXIF description put around an image's text
+var exifLabels = []string{" Camera: ", " Lens: ", " Taken: ", " GPS: "}
+
+// setupTermVocab exposes the FTS index's per-term document counts, used to
+// pick the words that set a file apart.
+func setupTermVocab() {
+	DB.Exec(`CREATE VIRTUAL TABLE IF NOT EXISTS files_fts_vocab USING fts5vocab(files_fts, 'row');`)
+}
+
+// visionText is the tags and OCR part of an image summary, without the EXIF
+// description appended after it. Other summaries are returned as they are.
+func visionText(summary string) string {
+	if !strings.HasPrefix(summary, "Tags: [") {
+		return summary
+	}
+	end := len(summary)
+	for _, label := range exifLabels {
+		if i := strings.Index(summary, label); i >= 0 && i < end {
+			end = i
+		}
+	}
+	text := strings.Replace(summary[:end], "Tags: [", "", 1)
+	text = strings.Replace(text, "] Content: ", " ", 1)
+	return text
+}
+
+// FindSimilar returns files like the one at path ("more like this"). The
+// file's stored chunk vectors are compared against VectorIndex, so nothing is
+// embedded again, and its most distinctive words are run through FTS; the two
+// lists are fused like HybridSearch sources. Images match by their vision
+// tags and OCR text. The file and its own entries are never returned; for an
+// entry, neither are its parent file and the other entries of that file.
+func FindSimilar(ctx context.Context, path string) ([]SearchResult, error) {
+	var id, root int
+	var filename, summary string
+	err := DB.QueryRowContext(ctx, "SELECT id, COALESCE(parent_id, id), filename, COALESCE(summary, '') FROM files WHERE path = ?", path).
+		Scan(&id, &root, &filename, &summary)
+	if err != nil {
+		return nil, err
+	}
+
+	// Calendar events, contacts etc. of the same file are not "similar files"
+	own := map[int]bool{id: true, root: true}
+	if rows, err := DB.QueryContext(ctx, "SELECT id FROM files WHERE parent_id = ?", root); err == nil {
+		for rows.Next() {
+			var child int
+			if rows.Scan(&child) == nil {
+				own[child] = true
+			}
+		}
+		rows.Close()
+	}
+
+	vectorResults, _ := similarByVectors(ctx, id, own)
+	keywordResults, _ := similarByTerms(ctx, id, root, filename, visionText(summary))
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	weights := CurrentSettings.Ranking
+	k := weights.k()
+	type merged struct {
+		Result SearchResult
+		Score  float32
+	}
+	scoreMap := make(map[string]*merged)
+	for _, source := range []struct {
+		Results []SearchResult
+		Weight  float64
+	}{
+		{keywordResults, weights.KeywordWeight},
+		{vectorResults, weights.SemanticWeight},
+	} {
+		if source.Weight <= 0 {
+			continue
+		}
+		for i, res := range source.Results {
+			m, found := scoreMap[res.Path]
+			if !found {
+				m = &merged{Result: res}
+				scoreMap[res.Path] = m
+			} else if len(res.Snippet) > len(m.Result.Snippet) {
+				m.Result.Snippet = res.Snippet
+			}
+			m.Score += float32(source.Weight / (k + float64(i+1)))
+		}
+	}
+
+	all := make([]merged, 0, len(scoreMap))
+	for _, m := range scoreMap {
+		all = append(all, *m)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Score != all[j].Score {
+			return all[i].Score > all[j].Score
+		}
+		return all[i].Result.Path < all[j].Result.Path
+	})
+
+	results := make([]SearchResult, 0, min(len(all), similarMaxResults))
+	for _, m := range all[:min(len(all), similarMaxResults)] {
+		m.Result.Score = m.Score
+		m.Result.RelatedPath = RelatedPath(m.Result.Path)
+		if strings.Contains(m.Result.Path, VirtualSeparator) {
+			m.Result.Name = EntryName(m.Result.Path)
+		}
+		results = append(results, m.Result)
+	}
+	return results, nil
+}
+
+// similarByVectors uses the normalized mean of the file's stored chunks (one
+// per embedding model) as the query vector.
+func similarByVectors(ctx context.Context, id int, own map[int]bool) ([]SearchResult, error) {
+	if len(VectorIndex) == 0 {
+		return nil, nil
+	}
+	rows, err := DB.QueryContext(ctx, "SELECT COALESCE(model, 'minilm'), vector_blob FROM file_vectors WHERE file_id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	sums := make(map[string][]float32)
+	for rows.Next() {
+		var model string
+		var blob []byte
+		if rows.Scan(&model, &blob) != nil {
+			continue
+		}
+		vec := decodeVector(blob)
+		sum, ok := sums[model]
+		if !ok {
+			sums[model] = vec
+			continue
+		}
+		if len(sum) != len(vec) {
+			continue
+		}
+		for i, v := range vec {
+			sum[i] += v
+		}
+	}
+	rows.Close()
+
+	queryVecs := make(map[string][]float32, len(sums))
+	for model, sum := range sums {
+		var norm float64
+		for _, v := range sum {
+			norm += float64(v) * float64(v)
+		}
+		if norm == 0 {
+			continue
+		}
+		scale := float32(1 / math.Sqrt(norm))
+		for i := range sum {
+			sum[i] *= scale
+		}
+		queryVecs[model] = sum
+	}
+	if len(queryVecs) == 0 {
+		return nil, nil
+	}
+	return scoreVectors(ctx, queryVecs, func(fileID int) bool { return !own[fileID] }, "", nil, similarMaxResults*2)
+}
+
+// similarByTerms runs the file's highest tf-idf words as an OR query, leaving
+// out the file root and its entries (root is id for a top-level file).
+func similarByTerms(ctx context.Context, id, root int, filename, text string) ([]SearchResult, error) {
+	terms := distinctiveTerms(ctx, strings.TrimSuffix(filename, extOf(filename))+" "+text)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	quoted := make([]string, len(terms))
+	for i, t := range terms {
+		quoted[i] = `"` + t + `"`
+	}
+	return searchFTS(ctx, strings.Join(quoted, " OR "), terms[0], QueryFilters{},
+		" AND f.id NOT IN (?, ?) AND COALESCE(f.parent_id, 0) != ? ", []interface{}{id, root, root}, similarMaxResults*2)
+}
+
+// distinctiveTerms picks the words of text that are frequent in it but rare
+// in the index. Words only this file contains match nothing else and are skipped.
+func distinctiveTerms(ctx context.Context, text string) []string {
+	tf := make(map[string]int)
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		if len([]rune(w)) < similarMinWordLen || strings.IndexFunc(w, unicode.IsLetter) < 0 {
+			continue
+		}
+		tf[w]++
+	}
+
+	words := make([]string, 0, len(tf))
+	for w := range tf {
+		words = append(words, w)
+	}
+	sort.Slice(words, func(i, j int) bool {
+		if tf[words[i]] != tf[words[j]] {
+			return tf[words[i]] > tf[words[j]]
+		}
+		return words[i] < words[j]
+	})
+	words = words[:min(len(words), similarCandidates)]
+
+	var total int
+	if DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM files").Scan(&total) != nil || total == 0 {
+		return nil
+	}
+	stmt, err := DB.PrepareContext(ctx, "SELECT doc FROM files_fts_vocab WHERE term = ?")
+	if err != nil {
+		return nil
+	}
+	defer stmt.Close()
+
+	weight := make(map[string]float64, len(words))
+	var picked []string
+	for _, w := range words {
+		var df int
+		if err := stmt.QueryRowContext(ctx, w).Scan(&df); err != nil && err != sql.ErrNoRows {
+			return nil
+		}
+		if df < 2 {
+			continue
+		}
+		weight[w] = float64(tf[w]) * math.Log(float64(total)/float64(df))
+		if weight[w] > 0 {
+			picked = append(picked, w)
+		}
+	}
+	sort.Slice(picked, func(i, j int) bool {
+		if weight[picked[i]] != weight[picked[j]] {
+			return weight[picked[i]] > weight[picked[j]]
+		}
+		return picked[i] < picked[j]
+	})
+	return picked[:min(len(picked), similarTerms)]
+}
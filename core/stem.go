@@ -0,0 +1,71 @@
+package core
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Light suffix-stripping stemmers. The FTS index is not stemmed; stems are
+// used as prefix queries ("häuser" -> "haus*"), so they only have to be a
+// common prefix of the word's variants.
+var stemmers = map[string]func(string) string{
+	"en": stemEnglish,
+	"de": stemGerman,
+	"hi": stemHindi,
+}
+
+// StemTerm returns the stem of a lowercase term for a language, or the term itself.
+func StemTerm(lang, term string) string {
+	if stem, ok := stemmers[lang]; ok {
+		return stem(term)
+	}
+	return term
+}
+
+// stripSuffix removes the first matching suffix, keeping at least minStem runes.
+func stripSuffix(word string, suffixes []string, minStem int) string {
+	for _, suf := range suffixes {
+		if strings.HasSuffix(word, suf) && utf8.RuneCountInString(word)-utf8.RuneCountInString(suf) >= minStem {
+			return strings.TrimSuffix(word, suf)
+		}
+	}
+	return word
+}
+
+var englishSuffixes = []string{
+	"ational", "ations", "ation", "nesses", "ness", "ments", "ment", "ingly",
+	"ings", "ing", "ies", "ied", "edly", "ed", "ers", "er", "es", "ly", "y", "s",
+}
+
+func stemEnglish(word string) string {
+	stem := stripSuffix(word, englishSuffixes, 3)
+	// running -> runn -> run
+	if n := len(stem); n >= 4 && stem != word && stem[n-1] == stem[n-2] && !strings.ContainsRune("aeiouls", rune(stem[n-1])) {
+		stem = stem[:n-1]
+	}
+	return stem
+}
+
+var germanSuffixes = []string{
+	"ungen", "heiten", "keiten", "ung", "heit", "keit", "lich", "isch",
+	"ern", "em", "en", "er", "es", "e", "n", "s",
+}
+
+func stemGerman(word string) string {
+	// The FTS tokenizer folds umlauts, so do the same before stripping
+	word = strings.NewReplacer("ä", "a", "ö", "o", "ü", "u", "ß", "ss").Replace(word)
+	return stripSuffix(word, germanSuffixes, 3)
+}
+
+// Hindi inflectional suffixes (after Ramanathan & Rao), longest first.
+var hindiSuffixes = []string{
+	"ाएंगी", "ाएंगे", "ाऊंगी", "ाऊंगा", "ाइयाँ", "ाइयों", "ाइयां",
+	"ाएगी", "ाएगा", "ाओगी", "ाओगे", "एंगी", "एंगे", "ऊंगी", "ऊंगा",
+	"ियाँ", "ियों", "ियां", "ाकर", "ाते", "ाती", "ाता", "ाना", "ाने", "ानी",
+	"ाओं", "ाएं", "ुओं", "ुएं", "ुआं", "ों", "ें", "ीं", "ां", "ाँ",
+	"ो", "े", "ू", "ु", "ी", "ि", "ा",
+}
+
+func stemHindi(word string) string {
+	return stripSuffix(word, hindiSuffixes, 2)
+}
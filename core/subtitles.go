@@ -0,0 +1,163 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const MaxSubtitleSize = 5 * 1024 * 1024
+const SubtitleWindow = 60.0 // Seconds of speech per segment / embedding chunk
+
+// Cue is one timed caption.
+type Cue struct {
+	Start float64 // Seconds
+	End   float64
+	Text  string
+}
+
+// "00:14:32,000 --> 00:14:35,500" (SRT) or "14:32.000 --> 14:35.500 align:start" (VTT)
+var cueTimingRegex = regexp.MustCompile(`^\s*((?:\d+:)?\d{1,2}:\d{2}[.,]\d{1,3})\s+-->\s+((?:\d+:)?\d{1,2}:\d{2}[.,]\d{1,3})`)
+
+// Speaker/styling tags: <v Alice>, <i>, <00:01:02.000>, {\an8}
+var cueTagRegex = regexp.MustCompile(`<[^>]*>|\{\\[^}]*\}`)
+
+// readSubtitleContent indexes .srt/.vtt cues grouped into time windows, each
+// located as "at 00:14:32", and links the transcript to its video.
+func readSubtitleContent(path string) Extraction {
+	res := newExtraction("")
+
+	stat, err := os.Stat(path)
+	if err != nil || stat.Size() > MaxSubtitleSize {
+		return res
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return res
+	}
+
+	cues := parseCues(data)
+	if len(cues) == 0 {
+		return res
+	}
+
+	var sb strings.Builder
+	windowEnd := -1.0
+	for _, cue := range cues {
+		if cue.Start >= windowEnd {
+			if sb.Len() > 0 {
+				sb.WriteByte(' ')
+			}
+			res.Segments = append(res.Segments, Segment{Start: sb.Len(), Locator: "at " + formatTimestamp(cue.Start)})
+			windowEnd = cue.Start + SubtitleWindow
+		} else {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(cue.Text)
+	}
+	res.Text = sb.String()
+
+	if video := findSiblingVideo(path); video != "" {
+		res.Metadata.Add("video", video)
+	}
+	return res
+}
+
+// parseCues reads SRT and WebVTT. Both are "timing line, then text until a blank line";
+// SRT counters and VTT headers/NOTE blocks are skipped because they have no timing.
+func parseCues(data []byte) []Cue {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var cues []Cue
+	var current *Cue
+	var lines []string
+
+	flush := func() {
+		if current != nil {
+			current.Text = cleanText(cueTagRegex.ReplaceAllString(strings.Join(lines, " "), " "), false)
+			if current.Text != "" {
+				cues = append(cues, *current)
+			}
+		}
+		current = nil
+		lines = nil
+	}
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if m := cueTimingRegex.FindStringSubmatch(line); m != nil {
+			flush()
+			current = &Cue{Start: parseTimestamp(m[1]), End: parseTimestamp(m[2])}
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+		if current != nil {
+			lines = append(lines, line)
+		}
+	}
+	flush()
+	return cues
+}
+
+// parseTimestamp reads "01:02:03,456", "02:03.456" into seconds.
+func parseTimestamp(s string) float64 {
+	s = strings.Replace(s, ",", ".", 1)
+	var seconds float64
+	for _, part := range strings.Split(s, ":") {
+		v, _ := strconv.ParseFloat(part, 64)
+		seconds = seconds*60 + v
+	}
+	return seconds
+}
+
+func formatTimestamp(seconds float64) string {
+	total := int(seconds)
+	return fmt.Sprintf("%02d:%02d:%02d", total/3600, (total/60)%60, total%60)
+}
+
+// findSiblingVideo looks for "meeting.mp4" next to "meeting.srt" or "meeting.en.vtt".
+func findSiblingVideo(path string) string {
+	dir := filepath.Dir(path)
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+	candidates := []string{base}
+	if lang := filepath.Ext(base); lang != "" && len(lang) <= 6 {
+		candidates = append(candidates, strings.TrimSuffix(base, lang))
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	for _, name := range candidates {
+		for _, e := range entries {
+			if e.IsDir() || !isVideoFile(filepath.Ext(e.Name())) {
+				continue
+			}
+			if strings.EqualFold(strings.TrimSuffix(e.Name(), filepath.Ext(e.Name())), name) {
+				return filepath.Join(dir, e.Name())
+			}
+		}
+	}
+	return ""
+}
+
+// RelatedPath returns the file a result belongs to (e.g. the video of a transcript).
+func RelatedPath(path string) string {
+	var related string
+	DB.QueryRow(`
+		SELECT m.value FROM file_metadata m
+		JOIN files f ON f.id = m.file_id
+		WHERE f.path = ? AND m.key = 'video' LIMIT 1`, path).Scan(&related)
+	return related
+}
@@ -20,26 +20,40 @@ const (
 
 func InitTokenizer() {
 	fmt.Println("[AI] Loading Tokenizer Vocab...")
-	file, err := os.Open(GetDataPath("vocab.txt"))
+	vocab, err := loadVocab(GetDataPath("vocab.txt"))
 	if err != nil {
 		fmt.Printf("❌ [AI Error] Could not load vocab.txt: %v\n", err)
 		return
 	}
+	Vocab = vocab
+	fmt.Printf("✅ [AI] Tokenizer Ready (%d words).\n", len(Vocab))
+}
+
+// loadVocab reads a vocab.txt (one token per line, id = line number).
+func loadVocab(path string) (map[string]int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
 	defer file.Close()
 
-	Vocab = make(map[string]int64)
+	vocab := make(map[string]int64)
 	scanner := bufio.NewScanner(file)
 	var id int64 = 0
 	for scanner.Scan() {
 		line := scanner.Text()
-		Vocab[line] = id
+		vocab[line] = id
 		id++
 	}
-	fmt.Printf("✅ [AI] Tokenizer Ready (%d words).\n", len(Vocab))
+	return vocab, scanner.Err()
 }
 
 // Tokenize implements a simple WordPiece-style tokenizer
 func Tokenize(text string) []int64 {
+	return tokenizeWith(Vocab, text)
+}
+
+func tokenizeWith(vocab map[string]int64, text string) []int64 {
 	text = strings.ToLower(text)
 
 	var tokens []string
@@ -63,7 +77,7 @@ func Tokenize(text string) []int64 {
 	ids = append(ids, TokenCLS)
 
 	for _, word := range tokens {
-		if id, ok := Vocab[word]; ok {
+		if id, ok := vocab[word]; ok {
 			ids = append(ids, id)
 			continue
 		}
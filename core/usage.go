@@ -0,0 +1,214 @@
+package core
+
+import (
+	"context"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Every open is logged with the query that led to it. Ranking reads two
+// signals from that log: frecency (opens halving in weight every
+// UsageHalfLifeDays) and query affinity (opens picked for a query like the
+// current one, so "budget" learns which budget file the user means).
+
+const (
+	DefaultUsageHalfLifeDays = 14
+	usageMaxAge              = 365 * 24 * time.Hour // Older opens weigh next to nothing; they are pruned
+	affinityMinQueryLen      = 2
+	affinityPrefixWeight     = 0.5 // "budg" picked a file: half an open for "budget"
+	affinityMaxResults       = 20
+)
+
+// The usage log is read once and kept for the searches that follow (one per
+// keystroke); RecordOpen and ForgetPath drop the copy.
+var (
+	usageMutex  sync.Mutex
+	usageEvents []usageEvent // nil = not loaded
+)
+
+type usageEvent struct {
+	Path     string
+	Query    string
+	OpenedAt int64
+}
+
+func setupUsageEvents() {
+	invalidateUsage()
+	var name string
+	fresh := DB.QueryRow(`SELECT name FROM sqlite_master WHERE name = 'usage_events'`).Scan(&name) != nil
+
+	DB.Exec(`CREATE TABLE IF NOT EXISTS usage_events (
+		id INTEGER PRIMARY KEY,
+		path TEXT NOT NULL,
+		query TEXT NOT NULL DEFAULT '',
+		opened_at INTEGER NOT NULL
+	);`)
+	DB.Exec(`CREATE INDEX IF NOT EXISTS idx_usage_events_path ON usage_events(path);`)
+	DB.Exec(`CREATE INDEX IF NOT EXISTS idx_usage_events_time ON usage_events(opened_at);`)
+
+	if fresh {
+		// Lifetime counts from older versions carry over as a single open that starts decaying now
+		DB.Exec(`INSERT INTO usage_events (path, query, opened_at) SELECT path, '', ? FROM usage_stats`, time.Now().Unix())
+	}
+}
+
+// RecordOpen logs that path was opened from the results of rawQuery.
+func RecordOpen(path, rawQuery string) {
+	now := time.Now()
+	_, err := DB.Exec(`INSERT INTO usage_events (path, query, opened_at) VALUES (?, ?, ?)`, path, usageQueryKey(rawQuery), now.Unix())
+	if err != nil {
+		log.Printf("Error tracking usage: %v", err)
+		return
+	}
+	DB.Exec(`DELETE FROM usage_events WHERE opened_at < ?`, now.Add(-usageMaxAge).Unix())
+	IncrementUsage(path)
+	invalidateUsage()
+}
+
+// ForgetPath drops everything learned about how often and for what a path was opened.
+func ForgetPath(path string) error {
+	defer invalidateUsage()
+	if _, err := DB.Exec(`DELETE FROM usage_events WHERE path = ?`, path); err != nil {
+		return err
+	}
+	_, err := DB.Exec(`DELETE FROM usage_stats WHERE path = ?`, path)
+	return err
+}
+
+// usageQueryKey reduces a query to its words, so "ext:pdf Budget" and
+// "budget" count as the same question.
+func usageQueryKey(rawQuery string) string {
+	return strings.ToLower(ParseQuery(rawQuery).Plain)
+}
+
+// UsageScores are decayed open counts per path.
+type UsageScores struct {
+	Frecency map[string]float32
+	Affinity map[string]float32 // Only opens that came from a query like the current one
+}
+
+// loadUsageEvents returns the usage log, reading it only when it changed.
+func loadUsageEvents() []usageEvent {
+	usageMutex.Lock()
+	defer usageMutex.Unlock()
+	if usageEvents != nil {
+		return usageEvents
+	}
+
+	rows, err := DB.Query(`SELECT path, query, opened_at FROM usage_events`)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+	events := []usageEvent{}
+	for rows.Next() {
+		var e usageEvent
+		if rows.Scan(&e.Path, &e.Query, &e.OpenedAt) == nil {
+			events = append(events, e)
+		}
+	}
+	usageEvents = events
+	return events
+}
+
+func invalidateUsage() {
+	usageMutex.Lock()
+	usageEvents = nil
+	usageMutex.Unlock()
+}
+
+// LoadUsageScores decays every logged open by its age and sums them per path.
+func LoadUsageScores(rawQuery string, halfLifeDays float64) UsageScores {
+	scores := UsageScores{Frecency: map[string]float32{}, Affinity: map[string]float32{}}
+
+	key := usageQueryKey(rawQuery)
+	now := time.Now().Unix()
+	halfLife := halfLifeDays * 24 * 3600
+	for _, e := range loadUsageEvents() {
+		weight := float32(math.Exp2(-float64(max(now-e.OpenedAt, 0)) / halfLife))
+		scores.Frecency[e.Path] += weight
+		scores.Affinity[e.Path] += weight * queryAffinity(key, e.Query)
+	}
+	for path, a := range scores.Affinity {
+		if a == 0 {
+			delete(scores.Affinity, path)
+		}
+	}
+	return scores
+}
+
+// queryAffinity is 1 for the same query, affinityPrefixWeight when one was
+// typed on the way to the other ("budg" / "budget"), else 0.
+func queryAffinity(current, past string) float32 {
+	if len(current) < affinityMinQueryLen || len(past) < affinityMinQueryLen {
+		return 0
+	}
+	switch {
+	case current == past:
+		return 1
+	case strings.HasPrefix(current, past), strings.HasPrefix(past, current):
+		return affinityPrefixWeight
+	}
+	return 0
+}
+
+// AffinityResults returns the files most often picked for this query that
+// still exist and pass the filters, best first. They join the ranking even
+// when no search source found them.
+func AffinityResults(ctx context.Context, affinity map[string]float32, filters QueryFilters) []SearchResult {
+	paths := make([]string, 0, len(affinity))
+	for path := range affinity {
+		paths = append(paths, path)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		if affinity[paths[i]] != affinity[paths[j]] {
+			return affinity[paths[i]] > affinity[paths[j]]
+		}
+		return paths[i] < paths[j]
+	})
+	if len(paths) > affinityMaxResults {
+		paths = paths[:affinityMaxResults]
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+
+	query := `
+		SELECT f.path, COALESCE(substr(f.summary, 1, 200), ''), COALESCE(f.icon_data, ''), f.extension
+		FROM files f
+		WHERE f.path IN (` + strings.TrimSuffix(strings.Repeat("?,", len(paths)), ",") + `) `
+	args := make([]interface{}, 0, len(paths))
+	for _, p := range paths {
+		args = append(args, p)
+	}
+	clauses, clauseArgs := filters.sqlClauses()
+	query += clauses
+	args = append(args, clauseArgs...)
+
+	rows, err := DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	var results []SearchResult
+	for rows.Next() {
+		var res SearchResult
+		if rows.Scan(&res.Path, &res.Snippet, &res.IconData, &res.Extension) != nil {
+			continue
+		}
+		res.Score = affinity[res.Path]
+		results = append(results, res)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Path < results[j].Path
+	})
+	return results
+}
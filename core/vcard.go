@@ -0,0 +1,96 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"mime/quotedprintable"
+	"strings"
+)
+
+// readVCardContent turns every VCARD into a contact sub-entry.
+func readVCardContent(path string) Extraction {
+	res := newExtraction("")
+
+	var contact *SubEntry
+	var uid string
+	used := make(map[string]bool)
+	for _, line := range readContentLines(path) {
+		switch line.Name {
+		case "BEGIN":
+			if strings.EqualFold(line.Value, "VCARD") && len(res.Entries) < MaxSubEntries {
+				contact = &SubEntry{Metadata: Metadata{}}
+				uid = ""
+			}
+			continue
+		case "END":
+			if strings.EqualFold(line.Value, "VCARD") && contact != nil {
+				contact.Key = entryKey("contact", uid, len(res.Entries)+1, used)
+				contact.finish()
+				res.Entries = append(res.Entries, *contact)
+				contact = nil
+			}
+			continue
+		}
+		if contact == nil {
+			continue
+		}
+
+		value := vcardValue(line)
+		if value == "" {
+			continue
+		}
+
+		switch line.Name {
+		case "UID":
+			uid = value
+		case "FN":
+			contact.Name = value
+			contact.addText(value)
+		case "N":
+			// Family;Given;Additional;Prefix;Suffix -> "Given Additional Family"
+			parts := strings.Split(value, ";")
+			for len(parts) < 3 {
+				parts = append(parts, "")
+			}
+			name := strings.Join(strings.Fields(parts[1]+" "+parts[2]+" "+parts[0]), " ")
+			if contact.Name == "" {
+				contact.Name = name
+			}
+			contact.addText(name)
+		case "EMAIL":
+			contact.Metadata.Add("email", value)
+			contact.addText(value)
+		case "TEL":
+			contact.Metadata.Add("phone", value)
+			contact.addText(value)
+		case "ORG":
+			org := strings.Trim(strings.ReplaceAll(value, ";", " "), " ")
+			contact.Metadata.Add("organization", org)
+			contact.addText("Organization: " + org)
+		case "TITLE", "ROLE", "NOTE", "NICKNAME":
+			contact.addText(value)
+		case "ADR":
+			contact.addText(strings.Join(strings.Fields(strings.ReplaceAll(value, ";", " ")), " "))
+		case "BDAY":
+			contact.Metadata.Add("birthday", value)
+		}
+	}
+
+	res.Metadata.Add("contacts", fmt.Sprint(len(res.Entries)))
+	res.Text = fmt.Sprintf("Address book with %d contacts", len(res.Entries))
+	return res
+}
+
+// vcardValue decodes vCard 2.1 quoted-printable and TEXT escaping.
+func vcardValue(line contentLine) string {
+	value := line.Value
+	if strings.EqualFold(line.Params["ENCODING"], "QUOTED-PRINTABLE") {
+		if decoded, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(value))); err == nil {
+			value = string(decoded)
+		}
+	}
+	if strings.EqualFold(line.Params["ENCODING"], "b") || strings.EqualFold(line.Params["ENCODING"], "BASE64") {
+		return "" // Embedded photos
+	}
+	return strings.TrimSpace(unescapeText(value))
+}
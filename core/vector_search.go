@@ -1,21 +1,30 @@
 package core
 
 import (
+	"context"
 	"encoding/binary"
 	"fmt"
 	"math"
 	"sort"
+	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 type CachedVector struct {
 	FileID     int
 	ChunkIndex int
+	Start      int    // Byte offset of the chunk in the summary
+	Locator    string // e.g. "p. 12"
+	Model      string // Embedding model that produced Data
 	Data       []float32
 }
 
 var VectorIndex []CachedVector
 
+// Ranked candidates looked up (and filtered) per query
+const vectorLookupBatch = 100
+
 func CosineSimilarity(a, b []float32) float32 {
 	if len(a) != len(b) {
 		return 0.0
@@ -30,7 +39,7 @@ func CosineSimilarity(a, b []float32) float32 {
 func LoadVectorIndex() {
 	fmt.Print("Loading Vector Index into RAM... ")
 	startTime := time.Now()
-	rows, err := DB.Query("SELECT file_id, chunk_index, vector_blob FROM file_vectors")
+	rows, err := DB.Query("SELECT file_id, chunk_index, COALESCE(start_offset, 0), COALESCE(locator, ''), COALESCE(model, 'minilm'), vector_blob FROM file_vectors")
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 		return
@@ -39,87 +48,159 @@ func LoadVectorIndex() {
 
 	VectorIndex = []CachedVector{}
 	for rows.Next() {
-		var fileID, chunkIdx int
+		var fileID, chunkIdx, start int
+		var locator, model string
 		var blob []byte
-		if err := rows.Scan(&fileID, &chunkIdx, &blob); err != nil {
+		if err := rows.Scan(&fileID, &chunkIdx, &start, &locator, &model, &blob); err != nil {
 			continue
 		}
-		vecLen := len(blob) / 4
-		vec := make([]float32, vecLen)
-		for i := 0; i < vecLen; i++ {
-			bits := binary.LittleEndian.Uint32(blob[i*4 : (i+1)*4])
-			vec[i] = math.Float32frombits(bits)
-		}
-		VectorIndex = append(VectorIndex, CachedVector{FileID: fileID, ChunkIndex: chunkIdx, Data: vec})
+		VectorIndex = append(VectorIndex, CachedVector{FileID: fileID, ChunkIndex: chunkIdx, Start: start, Locator: locator, Model: model, Data: decodeVector(blob)})
 	}
 	fmt.Printf("Done! Loaded %d vectors in %v\n", len(VectorIndex), time.Since(startTime))
 }
 
-func SemanticSearch(query string, minTime int64, maxTime int64) ([]SearchResult, error) {
+// decodeVector reads a vector_blob (little-endian float32s).
+func decodeVector(blob []byte) []float32 {
+	vec := make([]float32, len(blob)/4)
+	for i := range vec {
+		bits := binary.LittleEndian.Uint32(blob[i*4 : (i+1)*4])
+		vec[i] = math.Float32frombits(bits)
+	}
+	return vec
+}
+
+// SemanticSearch returns up to limit files whose chunks are closest to the query.
+func SemanticSearch(ctx context.Context, query string, filters QueryFilters, limit int) ([]SearchResult, error) {
 	if !IsAIReady || len(VectorIndex) == 0 {
 		return nil, fmt.Errorf("AI not ready")
 	}
 
-	queryVec, err := GetEmbedding(query)
-	if err != nil {
+	// Embed the query once per loaded model; each vector is compared in its own space
+	queryVecs := make(map[string][]float32, len(Models))
+	var err error
+	for name, m := range Models {
+		var vec []float32
+		if vec, err = EmbedWith(m, query); err == nil {
+			queryVecs[name] = vec
+		}
+	}
+	if len(queryVecs) == 0 {
 		return nil, err
 	}
 
+	// Filters are checked only for the best scoring candidates, batch by batch
+	clauses, clauseArgs := filters.sqlClauses()
+	return scoreVectors(ctx, queryVecs, nil, clauses, clauseArgs, limit)
+}
+
+// scoreVectors ranks files by their best chunk's similarity to the query
+// vector of the chunk's model. keep, if set, decides which files are scored;
+// clauses (as from sqlClauses) drop ranked files when they are looked up.
+func scoreVectors(ctx context.Context, queryVecs map[string][]float32, keep func(fileID int) bool, clauses string, clauseArgs []interface{}, limit int) ([]SearchResult, error) {
 	type Match struct {
 		FileID int
 		Score  float32
+		Best   *CachedVector // Highest scoring chunk, used for the snippet/location
 	}
-	fileScores := make(map[int]float32)
+	fileScores := make(map[int]Match)
 	threshold := float32(0.35)
 
 	// Brute-force Cosine Similarity against RAM index
-	for _, doc := range VectorIndex {
+	for i := range VectorIndex {
+		// A newer query replaced this one; stop scoring
+		if i%4096 == 0 && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		doc := &VectorIndex[i]
+		if keep != nil && !keep(doc.FileID) {
+			continue
+		}
+		queryVec, ok := queryVecs[doc.Model]
+		if !ok {
+			continue
+		}
 		score := CosineSimilarity(queryVec, doc.Data)
 		if score > threshold {
-			if currentBest, exists := fileScores[doc.FileID]; !exists || score > currentBest {
-				fileScores[doc.FileID] = score
+			if currentBest, exists := fileScores[doc.FileID]; !exists || score > currentBest.Score {
+				fileScores[doc.FileID] = Match{FileID: doc.FileID, Score: score, Best: doc}
 			}
 		}
 	}
 
 	var matches []Match
-	for id, score := range fileScores {
-		matches = append(matches, Match{FileID: id, Score: score})
-	}
-	sort.Slice(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
-	if len(matches) > 10 {
-		matches = matches[:10]
+	for _, m := range fileScores {
+		matches = append(matches, m)
 	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].FileID < matches[j].FileID
+	})
 
+	// Files may have been removed since the index was loaded, or fail the
+	// filters, so keep walking batches of candidates until limit survive
 	var results []SearchResult
-	for _, m := range matches {
-		var path, summary, iconData, extension string
-		var modTime int64
-
-		err := DB.QueryRow("SELECT path, summary, modified_time, COALESCE(icon_data, ''), extension FROM files WHERE id = ?", m.FileID).Scan(&path, &summary, &modTime, &iconData, &extension)
-		if err != nil {
-			continue
+	for start := 0; start < len(matches) && len(results) < limit; start += vectorLookupBatch {
+		batch := matches[start:min(start+vectorLookupBatch, len(matches))]
+		placeholders := make([]string, len(batch))
+		args := make([]interface{}, 0, len(batch)+len(clauseArgs))
+		for i, m := range batch {
+			placeholders[i] = "?"
+			args = append(args, m.FileID)
 		}
+		args = append(args, clauseArgs...)
 
-		if minTime > 0 && modTime < minTime {
-			continue
+		rows, err := DB.QueryContext(ctx, "SELECT f.id, f.path, f.summary, COALESCE(f.icon_data, ''), f.extension FROM files f WHERE f.id IN ("+
+			strings.Join(placeholders, ",")+") "+clauses, args...)
+		if err != nil {
+			return nil, err
 		}
-		if maxTime > 0 && modTime > maxTime {
-			continue
+		found := make(map[int]SearchResult, len(batch))
+		for rows.Next() {
+			var id int
+			var res SearchResult
+			var summary string
+			if rows.Scan(&id, &res.Path, &summary, &res.IconData, &res.Extension) == nil {
+				res.Snippet = summary
+				found[id] = res
+			}
 		}
+		rows.Close()
 
-		displaySnippet := summary
-		if len(displaySnippet) > 200 {
-			displaySnippet = displaySnippet[:200] + "..."
+		for _, m := range batch {
+			res, ok := found[m.FileID]
+			if !ok {
+				continue
+			}
+			res.Snippet = snippetAt(res.Snippet, m.Best.Start, 200)
+			res.Score = m.Score
+			res.Location = m.Best.Locator
+			results = append(results, res)
+			if len(results) >= limit {
+				break
+			}
 		}
-
-		results = append(results, SearchResult{
-			Path:      path,
-			Snippet:   displaySnippet,
-			Score:     m.Score,
-			IconData:  iconData,
-			Extension: extension,
-		})
 	}
 	return results, nil
 }
+
+// snippetAt cuts about maxLen bytes of text starting at offset, without splitting a rune.
+func snippetAt(text string, offset, maxLen int) string {
+	if offset < 0 || offset >= len(text) {
+		offset = 0
+	}
+	for offset > 0 && !utf8.RuneStart(text[offset]) {
+		offset--
+	}
+
+	snippet := text[offset:]
+	if len(snippet) <= maxLen {
+		return snippet
+	}
+	end := maxLen
+	for end > 0 && !utf8.RuneStart(snippet[end]) {
+		end--
+	}
+	return snippet[:end] + "..."
+}
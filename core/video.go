@@ -0,0 +1,150 @@
+package core
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// VideoInfo is the container-level metadata we index for videos.
+type VideoInfo struct {
+	Title        string
+	Duration     float64 // Seconds
+	CreationTime time.Time
+	Width        int
+	Height       int
+	VideoCodec   string // Friendly name (H.264, HEVC, ...)
+	AudioCodec   string
+}
+
+// Pure-Go parsers exist only for these; other isVideoFile types keep filename-only search.
+func isParsableVideo(ext string) bool {
+	switch strings.ToLower(ext) {
+	case ".mp4", ".m4v", ".mov", ".mkv", ".webm":
+		return true
+	}
+	return false
+}
+
+var codecNames = map[string]string{
+	// MP4 sample entries
+	"avc1": "H.264", "avc3": "H.264", "hvc1": "HEVC", "hev1": "HEVC", "av01": "AV1",
+	"vp09": "VP9", "vp08": "VP8", "mp4v": "MPEG-4", "apch": "ProRes", "apcn": "ProRes",
+	"apcs": "ProRes", "apco": "ProRes", "ap4h": "ProRes",
+	"mp4a": "AAC", "ac-3": "AC-3", "ec-3": "E-AC-3", "Opus": "Opus", "alac": "ALAC", "fLaC": "FLAC",
+	// Matroska CodecIDs
+	"V_MPEG4/ISO/AVC": "H.264", "V_MPEGH/ISO/HEVC": "HEVC", "V_AV1": "AV1", "V_VP9": "VP9",
+	"V_VP8": "VP8", "V_MPEG4/ISO/ASP": "MPEG-4", "A_AAC": "AAC", "A_OPUS": "Opus",
+	"A_VORBIS": "Vorbis", "A_AC3": "AC-3", "A_EAC3": "E-AC-3", "A_FLAC": "FLAC", "A_DTS": "DTS",
+	"A_MPEG/L3": "MP3",
+}
+
+func friendlyCodec(id string) string {
+	if name, ok := codecNames[id]; ok {
+		return name
+	}
+	return id
+}
+
+// ReadVideoInfo reads MP4/MOV atoms or Matroska/WebM EBML headers.
+func ReadVideoInfo(path string) (*VideoInfo, error) {
+	return readVideoInfoAs(path, filepath.Ext(path))
+}
+
+// readVideoInfoAs reads the container format of ext (see readAudioTagsAs).
+func readVideoInfoAs(path, ext string) (*VideoInfo, error) {
+	switch strings.ToLower(ext) {
+	case ".mp4", ".m4v", ".mov":
+		m, err := ReadMP4(path)
+		if err != nil {
+			return nil, err
+		}
+		return &VideoInfo{
+			Title:        m.Tags["title"],
+			Duration:     m.Duration,
+			CreationTime: m.CreationTime,
+			Width:        m.Width,
+			Height:       m.Height,
+			VideoCodec:   friendlyCodec(m.VideoCodec),
+			AudioCodec:   friendlyCodec(m.AudioCodec),
+		}, nil
+	case ".mkv", ".webm":
+		m, err := ReadMKV(path)
+		if err != nil {
+			return nil, err
+		}
+		return &VideoInfo{
+			Title:        m.Title,
+			Duration:     m.Duration,
+			CreationTime: m.CreationTime,
+			Width:        m.Width,
+			Height:       m.Height,
+			VideoCodec:   friendlyCodec(m.VideoCodec),
+			AudioCodec:   friendlyCodec(m.AudioCodec),
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported video format")
+}
+
+// ResolutionLabel buckets dimensions the way people talk about them ("4K", "1080p").
+// Uses the short side so portrait phone videos bucket correctly.
+func ResolutionLabel(width, height int) string {
+	if width <= 0 || height <= 0 {
+		return ""
+	}
+	long, short := width, height
+	if short > long {
+		long, short = short, long
+	}
+	switch {
+	case long >= 7680 || short >= 4320:
+		return "8K"
+	case long >= 3840 || short >= 2160:
+		return "4K"
+	case short >= 1440:
+		return "1440p"
+	case short >= 1080:
+		return "1080p"
+	case short >= 720:
+		return "720p"
+	case short >= 480:
+		return "480p"
+	}
+	return "SD"
+}
+
+// addTo stores the video fields as metadata and searchable text.
+func (v *VideoInfo) addTo(ext *Extraction) {
+	var desc []string
+
+	if v.Title != "" {
+		ext.Metadata.Add("title", v.Title)
+		desc = append(desc, "Title: "+v.Title)
+	}
+	if label := ResolutionLabel(v.Width, v.Height); label != "" {
+		ext.Metadata.Add("width", strconv.Itoa(v.Width))
+		ext.Metadata.Add("height", strconv.Itoa(v.Height))
+		ext.Metadata.Add("resolution", label)
+		desc = append(desc, fmt.Sprintf("Resolution: %s %dx%d", label, v.Width, v.Height))
+	}
+	if v.VideoCodec != "" {
+		ext.Metadata.Add("codec", v.VideoCodec)
+		desc = append(desc, "Video: "+v.VideoCodec)
+	}
+	if v.AudioCodec != "" {
+		ext.Metadata.Add("audio_codec", v.AudioCodec)
+		desc = append(desc, "Audio: "+v.AudioCodec)
+	}
+	if v.Duration > 0 {
+		ext.Metadata.Add("duration", strconv.Itoa(int(v.Duration+0.5)))
+		desc = append(desc, "Duration: "+formatDuration(v.Duration))
+	}
+	if !v.CreationTime.IsZero() {
+		ext.Metadata.Add("created", v.CreationTime.Format(time.RFC3339))
+		ext.ContentTime = v.CreationTime.Unix()
+	}
+
+	ext.appendText(strings.Join(desc, " "))
+}
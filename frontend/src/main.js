@@ -3,12 +3,30 @@ const resultsList = document.getElementById('results-list');
 const appContainer = document.querySelector('.app-container');
 const launcherView = document.getElementById('launcher-view');
 const settingsView = document.getElementById('settings-view');
+const debugPanel = document.getElementById('debug-panel');
 
 let debounceTimer;
 let selectedIndex = 0;
 let currentResults = [];
 let isResetting = false; // NEW: Prevent event conflicts
 
+// Paging: results arrive PAGE_SIZE at a time; nextCursor continues the current query
+const PAGE_SIZE = 15;
+let currentQuery = "";
+let nextCursor = "";
+let totalResults = 0;
+let totalEstimated = false;
+let loadingMore = false;
+
+// Saved searches show up as "anything://saved/<name>" entries when typing "!"
+const SAVED_PREFIX = "anything://saved/";
+
+// Streaming: every search gets a new id; updates for older ids are stale and dropped
+let searchSeq = 0;
+let renderedSeq = 0;
+let finalSeq = 0; // Last search whose final (semantic) ranking has arrived
+let pendingQuery = "";
+
 // --- 1. WINDOW EVENT LISTENERS (CRITICAL) ---
 
 // REMOVE or COMMENT OUT the wails:window:show handler - it conflicts with window:reset
@@ -53,6 +71,9 @@ window.runtime.EventsOn("settings:open", async () => {
     console.log("⚙️ Opening settings");
     const settings = await window.go.main.App.GetSettings();
     document.getElementById('set-hotkey').value = settings.hotkey || "Alt+Space";
+    document.querySelectorAll('[data-ranking]').forEach(input => {
+        input.value = settings.ranking ? settings.ranking[input.dataset.ranking] : "";
+    });
 
     launcherView.classList.add('hidden');
     settingsView.classList.remove('hidden');
@@ -84,6 +105,27 @@ window.runtime.EventsOn("progress:update", (data) => {
     }
 });
 
+// Search results: keyword/filename matches first, then the merged semantic ranking
+window.runtime.EventsOn("search:results", (update) => {
+    if (update.QueryID !== searchSeq) return;
+
+    const firstUpdate = renderedSeq !== searchSeq;
+    renderedSeq = searchSeq;
+    if (update.Final) finalSeq = searchSeq;
+    currentQuery = pendingQuery;
+    setPage(update.Page);
+    currentResults = update.Page.Results || [];
+    // Keep the user's selection when the semantic results arrive
+    if (firstUpdate || selectedIndex >= currentResults.length) selectedIndex = 0;
+    renderResults(currentResults);
+    resizeWindow(currentResults.length > 0);
+});
+
+// Ranking debug panel (Ctrl+Shift+E): why each result is where it is
+window.runtime.EventsOn("search:explain", (explanation) => {
+    renderExplanation(explanation);
+});
+
 // --- 2. SETTINGS LOGIC ---
 
 window.closeSettings = () => {
@@ -99,6 +141,7 @@ window.switchTab = (tabName) => {
     document.querySelectorAll('.nav-item').forEach(el => el.classList.remove('active'));
 
     document.getElementById(`tab-${tabName}`).classList.add('active');
+    if (tabName === 'saved') loadSavedSearches();
 
     const navs = document.querySelectorAll('.nav-item');
     navs.forEach(n => {
@@ -120,12 +163,52 @@ document.getElementById('set-hotkey').addEventListener('change', async (e) => {
     await window.go.main.App.SaveSettings(settings);
 });
 
+// Ranking weights: one number input per RankingSettings field
+document.querySelectorAll('[data-ranking]').forEach(input => {
+    input.addEventListener('change', async (e) => {
+        const value = parseFloat(e.target.value);
+        if (isNaN(value) || value < 0) return;
+        const settings = await window.go.main.App.GetSettings();
+        settings.ranking[e.target.dataset.ranking] = value;
+        await window.go.main.App.SaveSettings(settings);
+    });
+});
+
+// Saved searches: name, !alias, query and matches new since each was last opened
+async function loadSavedSearches() {
+    const list = await window.go.main.App.GetSavedSearches();
+    const container = document.getElementById('saved-list');
+    container.innerHTML = '';
+    if (list.length === 0) {
+        container.innerHTML = '<div class="status-details">No saved searches yet. Press Ctrl+S in the launcher to save one.</div>';
+        return;
+    }
+    list.forEach(s => {
+        const row = document.createElement('div');
+        row.className = 'setting-row';
+        row.innerHTML = `
+            <div>
+                <div>${s.Name}${s.Alias ? ` <span class="location">!${s.Alias}</span>` : ''}${s.NewCount ? ` <span class="badge-new">${s.NewCount} new</span>` : ''}</div>
+                <div class="status-details">${s.Query}</div>
+            </div>
+            <button class="btn-secondary">Delete</button>
+        `;
+        row.querySelector('button').onclick = async () => {
+            await window.go.main.App.DeleteSavedSearch(s.Name);
+            loadSavedSearches();
+        };
+        container.appendChild(row);
+    });
+}
+
 // --- 3. SEARCH LOGIC (Standard) ---
 
 searchInput.addEventListener('input', (e) => {
     const query = e.target.value;
     clearTimeout(debounceTimer);
     if (query.trim() === "") {
+        searchSeq++;
+        window.go.main.App.CancelSearch();
         renderResults([]);
         resizeWindow(false);
         return;
@@ -141,7 +224,17 @@ document.addEventListener('keydown', (e) => {
     }
 
     // Normal Launcher Keys
-    if (e.key === 'Escape') {
+    if (e.key === 'Escape' && !debugPanel.classList.contains('hidden')) {
+        debugPanel.classList.add('hidden');
+        resizeWindow(currentResults.length > 0);
+    }
+    else if (e.key.toLowerCase() === 'e' && e.ctrlKey && e.shiftKey) {
+        e.preventDefault();
+        if (searchInput.value.trim() !== "") {
+            window.go.main.App.ExplainSearch(searchInput.value);
+        }
+    }
+    else if (e.key === 'Escape') {
         console.log("🔒 ESC pressed - hiding window");
         window.go.main.App.OnHide();
         window.runtime.WindowHide();
@@ -149,6 +242,14 @@ document.addEventListener('keydown', (e) => {
     else if (e.key === 'ArrowDown') {
         e.preventDefault();
         if (currentResults.length > 0) {
+            // Past the last loaded result: fetch the next page instead of wrapping
+            if (selectedIndex === currentResults.length - 1 && canLoadMore()) {
+                loadMore().then(() => {
+                    selectedIndex = Math.min(selectedIndex + 1, currentResults.length - 1);
+                    updateSelection();
+                });
+                return;
+            }
             selectedIndex = (selectedIndex + 1) % currentResults.length;
             updateSelection();
         }
@@ -160,9 +261,29 @@ document.addEventListener('keydown', (e) => {
             updateSelection();
         }
     }
+    else if (e.key.toLowerCase() === 'm' && e.ctrlKey) {
+        // Ctrl+M: more like this; replaces the list with files similar to the selection
+        if (currentResults.length > 0) {
+            e.preventDefault();
+            showSimilar(currentResults[selectedIndex].Path);
+        }
+    }
+    else if (e.key === 'Delete' && e.shiftKey) {
+        // Shift+Delete: stop boosting the selected result from past opens
+        if (currentResults.length > 0) {
+            e.preventDefault();
+            window.go.main.App.ForgetPath(currentResults[selectedIndex].Path)
+                .then(() => performSearch(searchInput.value));
+        }
+    }
+    else if (e.key.toLowerCase() === 's' && e.ctrlKey) {
+        // Ctrl+S: save the query; "Weekly invoices !inv" also recalls it as !inv
+        e.preventDefault();
+        saveCurrentSearch();
+    }
     else if (e.key === 'Enter') {
         if (currentResults.length > 0) {
-            window.go.main.App.OpenFile(currentResults[selectedIndex].Path);
+            openResult(currentResults[selectedIndex]);
         }
     }
 });
@@ -183,54 +304,190 @@ window.addEventListener('focus', () => {
     }
 });
 
-async function performSearch(query) {
+// performSearch starts a search; results arrive as "search:results" events
+// and the backend cancels whatever query was still running
+function performSearch(query) {
+    searchSeq++;
+    pendingQuery = query;
+    window.go.main.App.StartSearch(searchSeq, query, { offset: 0, limit: PAGE_SIZE })
+        .catch(err => console.error(err));
+}
+
+// canLoadMore: further pages continue the final ranking only; an earlier
+// keyword-only update would be replaced, appended pages and all
+function canLoadMore() {
+    return nextCursor !== "" && finalSeq === searchSeq;
+}
+
+// loadMore appends the next page of the current query ("show more" / scrolling)
+async function loadMore() {
+    if (!canLoadMore() || loadingMore) return;
+    loadingMore = true;
+    const seq = searchSeq;
+    try {
+        const page = await window.go.main.App.Search(currentQuery, { limit: PAGE_SIZE, cursor: nextCursor });
+        if (seq !== searchSeq) return; // A new search started meanwhile
+        setPage(page);
+        currentResults = currentResults.concat(page.Results || []);
+        renderResults(currentResults);
+    } catch (err) {
+        console.error(err);
+    } finally {
+        loadingMore = false;
+    }
+}
+
+// openResult opens a file, or a saved search suggested for "!..."; only a
+// picked saved search marks its new matches and counts as opened
+function openResult(res) {
+    if (res.Path.startsWith(SAVED_PREFIX)) {
+        window.go.main.App.OpenSavedSearch(res.Name)
+            .catch(err => console.error(err))
+            .finally(() => {
+                searchInput.value = res.Name;
+                performSearch(res.Name);
+            });
+        return;
+    }
+    window.go.main.App.OpenFile(res.Path);
+}
+
+// saveCurrentSearch stores the query in the box under a name (and optional !alias)
+async function saveCurrentSearch() {
+    const query = searchInput.value.trim();
+    if (query === "" || query.startsWith("!")) return;
+    const answer = window.prompt("Save this search as (add !alias to recall it by alias):", "");
+    if (!answer) return;
+    const words = answer.trim().split(/\s+/);
+    const alias = words.length > 1 && words[words.length - 1].startsWith("!") ? words.pop() : "";
+    try {
+        await window.go.main.App.SaveSearch(words.join(" "), alias, query);
+    } catch (err) {
+        window.alert(err);
+    }
+}
+
+// showSimilar lists files like the given one; typing starts a normal search again
+async function showSimilar(path) {
+    const seq = ++searchSeq;
+    window.go.main.App.CancelSearch();
     try {
-        currentResults = await window.go.main.App.Search(query);
+        const results = await window.go.main.App.FindSimilar(path);
+        if (seq !== searchSeq) return;
+        renderedSeq = seq;
+        finalSeq = seq;
+        setPage({ NextCursor: "", Total: results.length });
+        currentResults = results;
         selectedIndex = 0;
         renderResults(currentResults);
         resizeWindow(currentResults.length > 0);
-    } catch (err) { console.error(err); }
+    } catch (err) {
+        console.error(err);
+    }
+}
+
+function setPage(page) {
+    nextCursor = page.NextCursor || "";
+    totalResults = page.Total || 0;
+    totalEstimated = page.Estimated;
 }
 
+resultsList.addEventListener('scroll', () => {
+    if (resultsList.scrollTop + resultsList.clientHeight >= resultsList.scrollHeight - 50) {
+        loadMore();
+    }
+});
+
 function renderResults(results) {
     resultsList.innerHTML = '';
     if (!results || results.length === 0) {
         currentResults = [];
+        nextCursor = "";
         return;
     }
 
     results.forEach((res, index) => {
         const item = document.createElement('div');
         item.className = 'result-item';
-        if (index === 0) item.classList.add('selected');
+        if (index === selectedIndex) item.classList.add('selected');
 
-        item.onclick = () => window.go.main.App.OpenFile(res.Path);
+        item.onclick = () => openResult(res);
         item.onmouseenter = () => { selectedIndex = index; updateSelection(); };
 
-        const separator = res.Path.includes('\\') ? '\\' : '/';
-        const parts = res.Path.split(separator);
-        const filename = parts.pop();
-        const dir = parts.join(separator);
+        // Virtual entries ("calendar.ics::event/3") show their own name under the parent file
+        const isSaved = res.Path.startsWith(SAVED_PREFIX);
+        const isVirtual = res.Path.includes('::');
+        const realPath = isVirtual ? res.Path.split('::')[0] : res.Path;
+        const separator = realPath.includes('\\') ? '\\' : '/';
+        const parts = realPath.split(separator);
+        const filename = (isVirtual || isSaved) && res.Name ? res.Name : parts.pop();
+        const dir = isSaved ? res.Snippet : isVirtual ? realPath : parts.join(separator);
 
         let iconHtml = "";
         if (res.Path === "anything://settings") {
             iconHtml = `<div class="icon-wrapper" style="background: rgba(122, 162, 247, 0.2);"><i class="fa-solid fa-gear" style="font-size: 20px; color: #7aa2f7;"></i></div>`;
+        } else if (isSaved) {
+            iconHtml = `<div class="icon-wrapper" style="background: rgba(122, 162, 247, 0.2);"><i class="fa-solid fa-bookmark" style="font-size: 20px; color: #7aa2f7;"></i></div>`;
         } else if (res.IconData && res.IconData.startsWith("data:")) {
             iconHtml = `<div class="icon-wrapper"><img src="${res.IconData}" /></div>`;
         } else {
-            iconHtml = `<div class="icon-wrapper">${getIconForPath(res.Path)}</div>`;
+            iconHtml = `<div class="icon-wrapper">${getIconForPath(realPath)}</div>`;
         }
 
         item.innerHTML = `
             ${iconHtml}
             <div class="content">
-                <div class="filename">${filename}</div>
+                <div class="filename">${filename}${res.Location ? `<span class="location">${res.Location}</span>` : ''}${res.New ? '<span class="badge-new">new</span>' : ''}</div>
                 <div class="path">${dir}</div>
             </div>
+            ${res.RelatedPath ? `<div class="related" title="${res.RelatedPath}"><i class="fa-solid fa-film"></i></div>` : ''}
             ${res.Score ? `<div class="score">${res.Score.toFixed(1)}</div>` : ''}
         `;
+        const related = item.querySelector('.related');
+        if (related) {
+            related.onclick = (e) => {
+                e.stopPropagation();
+                window.go.main.App.OpenFile(res.RelatedPath);
+            };
+        }
         resultsList.appendChild(item);
     });
+
+    if (canLoadMore()) {
+        const more = document.createElement('div');
+        more.className = 'show-more';
+        more.innerText = `Show more (${results.length} of ${totalEstimated ? 'about ' : ''}${totalResults})`;
+        more.onclick = () => loadMore();
+        resultsList.appendChild(more);
+    }
+}
+
+function renderExplanation(explanation) {
+    const fmt = (n) => (n || 0).toFixed(4);
+    const sourceNames = ['keyword', 'semantic', 'fuzzy', 'acronym', 'affinity', 'usage'];
+
+    let text = `query: ${explanation.Query}\nfts:   ${explanation.Text || '-'}\nplain: ${explanation.Plain || '-'}\n`;
+    if (explanation.DateFrom || explanation.DateTo) {
+        text += `dates: ${explanation.DateFrom || '…'} .. ${explanation.DateTo || '…'}\n`;
+    }
+    text += `k = ${explanation.Ranking.rrf_k}\n\n`;
+
+    (explanation.Results || []).forEach((r, i) => {
+        text += `${i + 1}. ${r.Path}\n   final ${fmt(r.FinalScore)} = fused ${fmt(r.Fused)}` +
+            ` × app ${r.Boosts.App.toFixed(2)} × name ${r.Boosts.NamePrefix.toFixed(2)} × links ${r.Boosts.Backlinks.toFixed(2)}\n`;
+        sourceNames.forEach(name => {
+            const s = r.Sources[name];
+            if (s) text += `   ${name.padEnd(8)} #${s.Rank} score ${fmt(s.Score)} → +${fmt(s.Contribution)}\n`;
+        });
+        if (r.MatchedChunk) {
+            text += `   chunk${r.ChunkLocation ? ' (' + r.ChunkLocation + ')' : ''}: ${r.MatchedChunk.slice(0, 120).replace(/\s+/g, ' ')}\n`;
+        }
+    });
+
+    debugPanel.textContent = text;
+    debugPanel.classList.remove('hidden');
+    window.runtime.WindowSetSize(700, 560);
+    appContainer.classList.add('expanded');
 }
 
 function resizeWindow(hasResults) {
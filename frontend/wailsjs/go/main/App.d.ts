@@ -2,10 +2,34 @@
 // This file is automatically generated. DO NOT EDIT
 import {core} from '../models';
 
+export function CancelSearch():Promise<void>;
+
 export function CloseSettings():Promise<void>;
 
+export function DeleteSavedSearch(arg1:string):Promise<void>;
+
 export function DownloadModels():Promise<void>;
 
+export function ExplainSearch(arg1:string):Promise<core.SearchExplanation>;
+
+export function FindSimilar(arg1:string):Promise<Array<core.SearchResult>>;
+
+export function ForgetPath(arg1:string):Promise<void>;
+
+export function GetBacklinks(arg1:string):Promise<Array<string>>;
+
+export function GetEntities(arg1:string):Promise<Array<core.Entity>>;
+
+export function GetFlaggedFiles():Promise<Array<core.FlaggedFile>>;
+
+export function GetMetadata(arg1:string):Promise<{[key: string]: Array<string>}>;
+
+export function GetNewMatches(arg1:string):Promise<Array<core.SearchResult>>;
+
+export function GetOutgoingLinks(arg1:string):Promise<Array<string>>;
+
+export function GetSavedSearches():Promise<Array<core.SavedSearch>>;
+
 export function GetSettings():Promise<core.AppSettings>;
 
 export function GetThumbnail(arg1:string):Promise<string>;
@@ -14,10 +38,16 @@ export function OnHide():Promise<void>;
 
 export function OpenFile(arg1:string):Promise<void>;
 
+export function OpenSavedSearch(arg1:string):Promise<void>;
+
 export function OpenSettings():Promise<void>;
 
 export function RebuildIndex():Promise<void>;
 
+export function SaveSearch(arg1:string,arg2:string,arg3:string):Promise<void>;
+
 export function SaveSettings(arg1:core.AppSettings):Promise<void>;
 
-export function Search(arg1:string):Promise<Array<core.SearchResult>>;
+export function Search(arg1:string,arg2:core.SearchOptions):Promise<core.SearchPage>;
+
+export function StartSearch(arg1:number,arg2:string,arg3:core.SearchOptions):Promise<void>;
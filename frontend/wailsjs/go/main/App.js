@@ -2,14 +2,62 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function CancelSearch() {
+  return window['go']['main']['App']['CancelSearch']();
+}
+
 export function CloseSettings() {
   return window['go']['main']['App']['CloseSettings']();
 }
 
+export function DeleteSavedSearch(arg1) {
+  return window['go']['main']['App']['DeleteSavedSearch'](arg1);
+}
+
 export function DownloadModels() {
   return window['go']['main']['App']['DownloadModels']();
 }
 
+export function ExplainSearch(arg1) {
+  return window['go']['main']['App']['ExplainSearch'](arg1);
+}
+
+export function FindSimilar(arg1) {
+  return window['go']['main']['App']['FindSimilar'](arg1);
+}
+
+export function ForgetPath(arg1) {
+  return window['go']['main']['App']['ForgetPath'](arg1);
+}
+
+export function GetBacklinks(arg1) {
+  return window['go']['main']['App']['GetBacklinks'](arg1);
+}
+
+export function GetEntities(arg1) {
+  return window['go']['main']['App']['GetEntities'](arg1);
+}
+
+export function GetFlaggedFiles() {
+  return window['go']['main']['App']['GetFlaggedFiles']();
+}
+
+export function GetMetadata(arg1) {
+  return window['go']['main']['App']['GetMetadata'](arg1);
+}
+
+export function GetNewMatches(arg1) {
+  return window['go']['main']['App']['GetNewMatches'](arg1);
+}
+
+export function GetOutgoingLinks(arg1) {
+  return window['go']['main']['App']['GetOutgoingLinks'](arg1);
+}
+
+export function GetSavedSearches() {
+  return window['go']['main']['App']['GetSavedSearches']();
+}
+
 export function GetSettings() {
   return window['go']['main']['App']['GetSettings']();
 }
@@ -26,6 +74,10 @@ export function OpenFile(arg1) {
   return window['go']['main']['App']['OpenFile'](arg1);
 }
 
+export function OpenSavedSearch(arg1) {
+  return window['go']['main']['App']['OpenSavedSearch'](arg1);
+}
+
 export function OpenSettings() {
   return window['go']['main']['App']['OpenSettings']();
 }
@@ -34,10 +86,18 @@ export function RebuildIndex() {
   return window['go']['main']['App']['RebuildIndex']();
 }
 
+export function SaveSearch(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SaveSearch'](arg1, arg2, arg3);
+}
+
 export function SaveSettings(arg1) {
   return window['go']['main']['App']['SaveSettings'](arg1);
 }
 
-export function Search(arg1) {
-  return window['go']['main']['App']['Search'](arg1);
+export function Search(arg1, arg2) {
+  return window['go']['main']['App']['Search'](arg1, arg2);
+}
+
+export function StartSearch(arg1, arg2, arg3) {
+  return window['go']['main']['App']['StartSearch'](arg1, arg2, arg3);
 }
@@ -6,6 +6,14 @@ export namespace core {
 	    hotkey: string;
 	    ignored_paths: string[];
 	    allowed_extensions: string[];
+	    max_pdf_pages: number;
+	    language_models: {[key: string]: ModelConfig};
+	    sensitive_detection: boolean;
+	    redact_sensitive: boolean;
+	    skip_embedding_sensitive: boolean;
+	    sensitive_kinds: string[];
+	    sensitive_patterns: {[key: string]: string};
+	    ranking: RankingSettings;
 	
 	    static createFrom(source: any = {}) {
 	        return new AppSettings(source);
@@ -18,14 +26,352 @@ export namespace core {
 	        this.hotkey = source["hotkey"];
 	        this.ignored_paths = source["ignored_paths"];
 	        this.allowed_extensions = source["allowed_extensions"];
+	        this.max_pdf_pages = source["max_pdf_pages"];
+	        this.language_models = this.convertValues(source["language_models"], ModelConfig, true);
+	        this.sensitive_detection = source["sensitive_detection"];
+	        this.redact_sensitive = source["redact_sensitive"];
+	        this.skip_embedding_sensitive = source["skip_embedding_sensitive"];
+	        this.sensitive_kinds = source["sensitive_kinds"];
+	        this.sensitive_patterns = source["sensitive_patterns"];
+	        this.ranking = this.convertValues(source["ranking"], RankingSettings);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Entity {
+	    Type: string;
+	    Value: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Entity(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Type = source["Type"];
+	        this.Value = source["Value"];
+	    }
+	}
+	export class FeatureBoosts {
+	    App: number;
+	    NamePrefix: number;
+	    Backlinks: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new FeatureBoosts(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.App = source["App"];
+	        this.NamePrefix = source["NamePrefix"];
+	        this.Backlinks = source["Backlinks"];
+	    }
+	}
+	export class FieldFilter {
+	    Key: string;
+	    Value: string;
+	    Negate: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new FieldFilter(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Key = source["Key"];
+	        this.Value = source["Value"];
+	        this.Negate = source["Negate"];
+	    }
+	}
+	export class FlaggedFile {
+	    Path: string;
+	    Kinds: {[key: string]: number};
+	
+	    static createFrom(source: any = {}) {
+	        return new FlaggedFile(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Path = source["Path"];
+	        this.Kinds = source["Kinds"];
 	    }
 	}
+	export class ModelConfig {
+	    name: string;
+	    hidden_size: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ModelConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.hidden_size = source["hidden_size"];
+	    }
+	}
+	export class QueryFilters {
+	    MinTime: number;
+	    MaxTime: number;
+	    Fields: FieldFilter[];
+	    MinDuration: number;
+	    MaxDuration: number;
+	    Exclude: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new QueryFilters(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.MinTime = source["MinTime"];
+	        this.MaxTime = source["MaxTime"];
+	        this.Fields = this.convertValues(source["Fields"], FieldFilter);
+	        this.MinDuration = source["MinDuration"];
+	        this.MaxDuration = source["MaxDuration"];
+	        this.Exclude = source["Exclude"];
+	    }
+
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RankingSettings {
+	    rrf_k: number;
+	    keyword_weight: number;
+	    semantic_weight: number;
+	    fuzzy_weight: number;
+	    acronym_weight: number;
+	    usage_weight: number;
+	    affinity_weight: number;
+	    usage_half_life_days: number;
+	    app_boost: number;
+	    name_prefix_boost: number;
+	    backlink_boost: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new RankingSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.rrf_k = source["rrf_k"];
+	        this.keyword_weight = source["keyword_weight"];
+	        this.semantic_weight = source["semantic_weight"];
+	        this.fuzzy_weight = source["fuzzy_weight"];
+	        this.acronym_weight = source["acronym_weight"];
+	        this.usage_weight = source["usage_weight"];
+	        this.affinity_weight = source["affinity_weight"];
+	        this.usage_half_life_days = source["usage_half_life_days"];
+	        this.app_boost = source["app_boost"];
+	        this.name_prefix_boost = source["name_prefix_boost"];
+	        this.backlink_boost = source["backlink_boost"];
+	    }
+	}
+	export class SavedSearch {
+	    Name: string;
+	    Alias: string;
+	    Query: string;
+	    CreatedAt: number;
+	    LastOpenedAt: number;
+	    NewCount: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SavedSearch(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Name = source["Name"];
+	        this.Alias = source["Alias"];
+	        this.Query = source["Query"];
+	        this.CreatedAt = source["CreatedAt"];
+	        this.LastOpenedAt = source["LastOpenedAt"];
+	        this.NewCount = source["NewCount"];
+	    }
+	}
+	export class ScoreComponents {
+	    Path: string;
+	    Sources: {[key: string]: SourceScore};
+	    MatchedChunk: string;
+	    ChunkLocation: string;
+	    Fused: number;
+	    Boosts: FeatureBoosts;
+	    FinalScore: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ScoreComponents(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Path = source["Path"];
+	        this.Sources = this.convertValues(source["Sources"], SourceScore, true);
+	        this.MatchedChunk = source["MatchedChunk"];
+	        this.ChunkLocation = source["ChunkLocation"];
+	        this.Fused = source["Fused"];
+	        this.Boosts = this.convertValues(source["Boosts"], FeatureBoosts);
+	        this.FinalScore = source["FinalScore"];
+	    }
+
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SearchExplanation {
+	    Query: string;
+	    Text: string;
+	    Plain: string;
+	    Filters: QueryFilters;
+	    DateFrom: string;
+	    DateTo: string;
+	    Ranking: RankingSettings;
+	    Results: ScoreComponents[];
+	
+	    static createFrom(source: any = {}) {
+	        return new SearchExplanation(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Query = source["Query"];
+	        this.Text = source["Text"];
+	        this.Plain = source["Plain"];
+	        this.Filters = this.convertValues(source["Filters"], QueryFilters);
+	        this.DateFrom = source["DateFrom"];
+	        this.DateTo = source["DateTo"];
+	        this.Ranking = this.convertValues(source["Ranking"], RankingSettings);
+	        this.Results = this.convertValues(source["Results"], ScoreComponents);
+	    }
+
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SearchOptions {
+	    offset: number;
+	    limit: number;
+	    cursor: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SearchOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.offset = source["offset"];
+	        this.limit = source["limit"];
+	        this.cursor = source["cursor"];
+	    }
+	}
+	export class SearchPage {
+	    Results: SearchResult[];
+	    Total: number;
+	    Estimated: boolean;
+	    NextCursor: string;
+	    Explain?: ScoreComponents[];
+	
+	    static createFrom(source: any = {}) {
+	        return new SearchPage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Results = this.convertValues(source["Results"], SearchResult);
+	        this.Total = source["Total"];
+	        this.Estimated = source["Estimated"];
+	        this.NextCursor = source["NextCursor"];
+	        this.Explain = this.convertValues(source["Explain"], ScoreComponents);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class SearchResult {
 	    Path: string;
 	    Snippet: string;
 	    Score: number;
 	    IconData: string;
 	    Extension: string;
+	    Location: string;
+	    RelatedPath: string;
+	    Name: string;
+	    New: boolean;
 	
 	    static createFrom(source: any = {}) {
 	        return new SearchResult(source);
@@ -38,6 +384,26 @@ export namespace core {
 	        this.Score = source["Score"];
 	        this.IconData = source["IconData"];
 	        this.Extension = source["Extension"];
+	        this.Location = source["Location"];
+	        this.RelatedPath = source["RelatedPath"];
+	        this.Name = source["Name"];
+	        this.New = source["New"];
+	    }
+	}
+	export class SourceScore {
+	    Rank: number;
+	    Score: number;
+	    Contribution: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SourceScore(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Rank = source["Rank"];
+	        this.Score = source["Score"];
+	        this.Contribution = source["Contribution"];
 	    }
 	}
 
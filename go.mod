@@ -3,6 +3,7 @@ module Anything
 go 1.24.1
 
 require (
+	github.com/BurntSushi/toml v1.4.0
 	github.com/fcjr/geticon v0.1.3
 	github.com/ledongthuc/pdf v0.0.0-20250511090121-5959a4027728
 	github.com/mattn/go-sqlite3 v1.14.32
@@ -11,6 +12,7 @@ require (
 	github.com/wailsapp/wails/v2 v2.11.0
 	github.com/yalue/onnxruntime_go v1.20.0
 	golang.org/x/image v0.23.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (